@@ -16,8 +16,12 @@ func (ast *AstPrinter) print(statements []Stmt) string {
 			value, _ := stmtExpr.Expression.accept(ast)
 			sb.WriteString(fmt.Sprintf(" (%v)", value))
 		} else if stmtPrint, ok := stmt.(StmtPrint); ok {
-			value, _ := stmtPrint.Expression.accept(ast)
-			sb.WriteString(fmt.Sprintf(" (print (%v))", value))
+			sb.WriteString(" (print")
+			for _, expr := range stmtPrint.Expressions {
+				value, _ := expr.accept(ast)
+				sb.WriteString(fmt.Sprintf(" (%v)", value))
+			}
+			sb.WriteString(")")
 		} else {
 			sb.WriteString("statement without expression")
 		}
@@ -31,6 +35,14 @@ func (ast *AstPrinter) VisitExprCall(expr ExprCall) (interface{}, error) {
 	return "exprCall", nil
 }
 
+func (ast *AstPrinter) VisitExprGet(expr ExprGet) (interface{}, error) {
+	return ast.parenthesize("get "+expr.Name.Lexeme, expr.Object), nil
+}
+
+func (ast *AstPrinter) VisitExprSet(expr ExprSet) (interface{}, error) {
+	return ast.parenthesize("set "+expr.Name.Lexeme, expr.Object, expr.Value), nil
+}
+
 func (ast *AstPrinter) VisitExprLogical(expr ExprLogical) (interface{}, error) {
 	return ast.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
 }
@@ -63,6 +75,14 @@ func (ast *AstPrinter) VisitExprUnary(expr ExprUnary) (interface{}, error) {
 	return ast.parenthesize(expr.Operator.Lexeme, expr.Right), nil
 }
 
+func (ast *AstPrinter) VisitExprAwait(expr ExprAwait) (interface{}, error) {
+	return ast.parenthesize("await", expr.Expression), nil
+}
+
+func (ast *AstPrinter) VisitExprInterpolation(expr ExprInterpolation) (interface{}, error) {
+	return ast.parenthesize("interpolate", expr.Parts...), nil
+}
+
 func (ast *AstPrinter) parenthesize(name string, exprs ...Expr) string {
 	var sb strings.Builder
 
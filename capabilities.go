@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+const (
+	CAPABILITY_CORE        = "core"
+	CAPABILITY_MATH        = "math"
+	CAPABILITY_IO          = "io"
+	CAPABILITY_OS          = "os"
+	CAPABILITY_TIME        = "time"
+	CAPABILITY_CONCURRENCY = "concurrency"
+)
+
+// Globals provided by the interpreter grouped by the capability they require
+var capabilities = map[string]map[string]interface{}{
+	CAPABILITY_CORE:        {},
+	CAPABILITY_MATH:        {},
+	CAPABILITY_IO:          {},
+	CAPABILITY_OS:          {},
+	CAPABILITY_TIME:        {},
+	CAPABILITY_CONCURRENCY: {},
+}
+
+// DeniedLoxCallable stands in for a native whose capability was not granted
+type DeniedLoxCallable struct {
+	name       string
+	capability string
+	arity      int
+}
+
+// registerNatives wraps Go functions as natives of the given capability, names
+// must be unique across capabilities
+func registerNatives(capability string, natives map[string]interface{}) {
+	for name, function := range natives {
+		for other, values := range capabilities {
+			if _, ok := values[name]; ok {
+				panic(fmt.Sprintf("native '%v' of capability '%v' is already defined by '%v'", name, capability, other))
+			}
+		}
+
+		capabilities[capability][name] = NewNativeLoxCallable(name, function)
+	}
+}
+
+func (intr *Interpreter) defineBuiltins() *Environment {
+	builtins := NewEnvironment(nil)
+
+	for capability, values := range capabilities {
+		allowed := intr.isAllowed(capability)
+
+		for name, value := range values {
+			if allowed && !intr.disabled[name] {
+				builtins.Define(name, value)
+			} else if callable, ok := value.(LoxCallable); ok {
+				builtins.Define(name, DeniedLoxCallable{
+					name:       name,
+					capability: capability,
+					arity:      callable.Arity(),
+				})
+			}
+		}
+	}
+
+	return builtins
+}
+
+func (intr *Interpreter) isAllowed(capability string) bool {
+	return intr.capabilities == nil || intr.capabilities[capability]
+}
+
+func (lc DeniedLoxCallable) Arity() int {
+	return lc.arity
+}
+
+func (lc DeniedLoxCallable) Call(intr *Interpreter, arguments []interface{}) (interface{}, error) {
+	if intr.isAllowed(lc.capability) {
+		return nil, RuntimeError{
+			Message: fmt.Sprintf("Permission denied: '%v' has been disabled", lc.name),
+		}
+	}
+
+	return nil, RuntimeError{
+		Message: fmt.Sprintf("Permission denied: '%v' requires the '%v' capability", lc.name, lc.capability),
+	}
+}
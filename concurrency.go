@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// LoxTask is the handle of a callable running on its own goroutine
+type LoxTask struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+type LoxChannel struct {
+	ch chan interface{}
+}
+
+type LoxSelectResult struct {
+	Index    int         `lox:"index,readonly"`
+	Channel  *LoxChannel `lox:"channel,readonly"`
+	Value    interface{} `lox:"value,readonly"`
+	Ok       bool        `lox:"ok,readonly"`
+	TimedOut bool        `lox:"timedOut,readonly"`
+}
+
+type LoxWaitGroup struct {
+	wg sync.WaitGroup
+}
+
+func init() {
+	registerNatives(CAPABILITY_CONCURRENCY, map[string]interface{}{
+		"spawn":       spawn,
+		"channel":     newLoxChannel,
+		"send":        (*LoxChannel).Send,
+		"recv":        (*LoxChannel).Recv,
+		"recvTimeout": (*LoxChannel).RecvTimeout,
+		"close":       (*LoxChannel).Close,
+		"select":      selectChannels,
+		"waitGroup":   func() *LoxWaitGroup { return &LoxWaitGroup{} },
+	})
+}
+
+// spawn calls the callable on a new goroutine, it gets its own interpreter
+// state but shares globals and closed-over variables with the caller
+func spawn(intr *Interpreter, callable LoxCallable, arguments ...interface{}) *LoxTask {
+	task := &LoxTask{done: make(chan struct{})}
+	child := intr.fork()
+
+	go func() {
+		defer close(task.done)
+		defer func() {
+			if r := recover(); r != nil {
+				task.err = RuntimeError{Message: fmt.Sprintf("Spawned function panicked: %v", r)}
+			}
+		}()
+
+		task.value, task.err = child.call(callable, arguments, Token{})
+	}()
+
+	return task
+}
+
+// Join waits for the task to finish and returns its result, errors raised by
+// the task are raised again by the caller
+func (task *LoxTask) Join() (interface{}, error) {
+	<-task.done
+	return task.value, task.err
+}
+
+func newLoxChannel(intr *Interpreter, capacity ...int) (*LoxChannel, error) {
+	size := 0
+	if len(capacity) > 1 {
+		return nil, errors.New("Expected at most 1 argument")
+	} else if len(capacity) == 1 {
+		size = capacity[0]
+	}
+
+	if size < 0 {
+		return nil, errors.New("Channel capacity can't be negative")
+	} else if size > MAX_ALLOCATION/VALUE_SIZE {
+		return nil, errTooLarge(Token{})
+	}
+
+	// the buffer is allocated upfront
+	if err := intr.reserve(Token{}, size*VALUE_SIZE); err != nil {
+		return nil, err
+	}
+
+	return &LoxChannel{ch: make(chan interface{}, size)}, nil
+}
+
+func (channel *LoxChannel) Send(value interface{}) (err error) {
+	defer func() {
+		if recover() != nil {
+			err = errors.New("Can't send on a closed channel")
+		}
+	}()
+
+	channel.ch <- value
+	return nil
+}
+
+// Recv returns nil once the channel is closed and drained, it blocks until
+// then so receiving from a channel nobody sends to never returns
+func (channel *LoxChannel) Recv() interface{} {
+	return <-channel.ch
+}
+
+// RecvTimeout waits at most the given milliseconds for a value, the result is
+// the same as the one of select with timedOut set when nothing was received
+func (channel *LoxChannel) RecvTimeout(milliseconds float64) (*LoxSelectResult, error) {
+	if milliseconds < 0 {
+		return nil, errors.New("Timeout can't be negative")
+	}
+
+	timer := time.NewTimer(durationOf(milliseconds))
+	defer timer.Stop()
+
+	select {
+	case value, ok := <-channel.ch:
+		return &LoxSelectResult{Channel: channel, Value: value, Ok: ok}, nil
+	case <-timer.C:
+		return &LoxSelectResult{Channel: channel, TimedOut: true}, nil
+	}
+}
+
+func (channel *LoxChannel) Close() (err error) {
+	defer func() {
+		if recover() != nil {
+			err = errors.New("Channel is already closed")
+		}
+	}()
+
+	close(channel.ch)
+	return nil
+}
+
+// selectChannels waits until any of the channels can be received from
+func selectChannels(channels ...*LoxChannel) (*LoxSelectResult, error) {
+	if len(channels) == 0 {
+		return nil, errors.New("Expected at least 1 channel")
+	}
+
+	cases := make([]reflect.SelectCase, len(channels))
+	for i, channel := range channels {
+		cases[i] = reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(channel.ch),
+		}
+	}
+
+	chosen, value, ok := reflect.Select(cases)
+
+	var received interface{}
+	if ok {
+		received = value.Interface()
+	}
+
+	return &LoxSelectResult{
+		Index:   chosen,
+		Channel: channels[chosen],
+		Value:   received,
+		Ok:      ok,
+	}, nil
+}
+
+func (wg *LoxWaitGroup) Add(delta int) (err error) {
+	defer func() {
+		if recover() != nil {
+			err = errors.New("Wait group counter can't be negative")
+		}
+	}()
+
+	wg.wg.Add(delta)
+	return nil
+}
+
+func (wg *LoxWaitGroup) Done() (err error) {
+	defer func() {
+		if recover() != nil {
+			err = errors.New("Wait group counter can't be negative")
+		}
+	}()
+
+	wg.wg.Done()
+	return nil
+}
+
+func (wg *LoxWaitGroup) Wait() {
+	wg.wg.Wait()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errCoroutineClosed unwinds the body of a coroutine cancelled while suspended
+var errCoroutineClosed = errors.New("Coroutine was closed")
+
+type coroutineResult struct {
+	value interface{}
+	err   error
+	done  bool
+}
+
+// coroutine runs a function on its own goroutine handing control back and
+// forth with whoever resumes it, so only one of them is running at any time
+type coroutine struct {
+	body     func(co *coroutine) (interface{}, error)
+	resumeCh chan interface{}
+	yieldCh  chan coroutineResult
+	cancelCh chan struct{}
+	started  bool
+	done     bool
+}
+
+func newCoroutine(body func(co *coroutine) (interface{}, error)) *coroutine {
+	return &coroutine{
+		body:     body,
+		resumeCh: make(chan interface{}),
+		yieldCh:  make(chan coroutineResult),
+		cancelCh: make(chan struct{}),
+	}
+}
+
+// resume runs the coroutine until it yields or finishes, the value is handed
+// to the pending yield (it is ignored when the coroutine starts)
+func (co *coroutine) resume(value interface{}) coroutineResult {
+	if co.done {
+		return coroutineResult{done: true}
+	}
+
+	if !co.started {
+		co.started = true
+		go co.run()
+	} else {
+		co.resumeCh <- value
+	}
+
+	result := <-co.yieldCh
+	co.done = result.done
+	return result
+}
+
+// yield hands the value to the resumer and blocks until resumed again or
+// cancelled, in which case the error must be returned to stop the body
+func (co *coroutine) yield(value interface{}) (interface{}, error) {
+	co.yieldCh <- coroutineResult{value: value}
+
+	select {
+	case resumed := <-co.resumeCh:
+		return resumed, nil
+	case <-co.cancelCh:
+		return nil, errCoroutineClosed
+	}
+}
+
+// cancel stops a suspended coroutine and waits for its goroutine to exit,
+// it is finished afterwards
+func (co *coroutine) cancel() {
+	if co.done {
+		return
+	}
+
+	co.done = true
+	if co.started {
+		close(co.cancelCh)
+		<-co.yieldCh
+	}
+}
+
+func (co *coroutine) run() {
+	result := coroutineResult{done: true}
+	defer func() {
+		if r := recover(); r != nil {
+			result.err = RuntimeError{Message: fmt.Sprintf("Coroutine panicked: %v", r)}
+		}
+
+		co.yieldCh <- result
+	}()
+
+	result.value, result.err = co.body(co)
+}
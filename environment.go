@@ -1,11 +1,22 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 type Environment struct {
 	Enclosing *Environment
 	Closure   *Environment
 	Values    map[string]interface{}
+
+	// closures can be shared between goroutines (e.g. spawned functions)
+	mutex sync.RWMutex
+
+	// bytes charged for the scope and its variables, given back when the
+	// scope is left unless a function captured it
+	size     int64
+	captured bool
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
@@ -16,11 +27,18 @@ func NewEnvironment(enclosing *Environment) *Environment {
 }
 
 func (env *Environment) Define(name string, value interface{}) {
+	env.mutex.Lock()
+	defer env.mutex.Unlock()
+
 	env.Values[name] = value
 }
 
 func (env *Environment) Get(token Token) (interface{}, error) {
-	if value, ok := env.Values[token.Lexeme]; ok {
+	env.mutex.RLock()
+	value, ok := env.Values[token.Lexeme]
+	env.mutex.RUnlock()
+
+	if ok {
 		return value, nil
 	} else if env.Enclosing != nil {
 		return env.Enclosing.Get(token)
@@ -33,8 +51,14 @@ func (env *Environment) Get(token Token) (interface{}, error) {
 }
 
 func (env *Environment) Assign(token Token, value interface{}) error {
-	if _, ok := env.Values[token.Lexeme]; ok {
+	env.mutex.Lock()
+	_, ok := env.Values[token.Lexeme]
+	if ok {
 		env.Values[token.Lexeme] = value
+	}
+	env.mutex.Unlock()
+
+	if ok {
 		return nil
 	} else if env.Enclosing != nil {
 		return env.Enclosing.Assign(token, value)
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// EventLoop is a queue of tasks run one after the other by the interpreter,
+// timers and other asynchronous work push their callbacks into it
+type EventLoop struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	queue   []func() error
+	pending int
+}
+
+type promiseResult struct {
+	value interface{}
+	err   error
+}
+
+// LoxPromise is the eventual result of an async function or a timer
+type LoxPromise struct {
+	mutex     sync.Mutex
+	loop      *EventLoop
+	settled   bool
+	awaited   bool
+	outcome   promiseResult
+	callbacks []func() error
+}
+
+func init() {
+	registerNatives(CAPABILITY_TIME, map[string]interface{}{
+		"sleep": sleep,
+		"timer": timer,
+	})
+}
+
+func NewEventLoop() *EventLoop {
+	loop := &EventLoop{}
+	loop.cond = sync.NewCond(&loop.mutex)
+	return loop
+}
+
+func (loop *EventLoop) enqueue(task func() error) {
+	loop.mutex.Lock()
+	loop.queue = append(loop.queue, task)
+	loop.mutex.Unlock()
+
+	loop.cond.Broadcast()
+}
+
+// hold registers work that will enqueue a task in the future (e.g. a timer)
+// so the loop keeps waiting for it, release must be called once it's queued
+func (loop *EventLoop) hold() {
+	loop.mutex.Lock()
+	defer loop.mutex.Unlock()
+
+	loop.pending += 1
+}
+
+func (loop *EventLoop) release() {
+	loop.mutex.Lock()
+	loop.pending -= 1
+	loop.mutex.Unlock()
+
+	loop.cond.Broadcast()
+}
+
+// after enqueues the task once the duration elapsed
+func (loop *EventLoop) after(duration time.Duration, task func() error) {
+	loop.hold()
+	time.AfterFunc(duration, func() {
+		loop.enqueue(task)
+		loop.release()
+	})
+}
+
+// next blocks until there is a task to run, it returns false once there is
+// no pending work left
+func (loop *EventLoop) next() (func() error, bool) {
+	loop.mutex.Lock()
+	defer loop.mutex.Unlock()
+
+	for len(loop.queue) == 0 && loop.pending > 0 {
+		loop.cond.Wait()
+	}
+
+	if len(loop.queue) == 0 {
+		return nil, false
+	}
+
+	task := loop.queue[0]
+	loop.queue = loop.queue[1:]
+	return task, true
+}
+
+// RunEventLoop runs queued tasks until no pending work remains, errors are
+// reported as they happen and the first one is returned
+func (intr *Interpreter) RunEventLoop() error {
+	return intr.runEventLoop(func() bool { return false })
+}
+
+func (intr *Interpreter) runEventLoop(until func() bool) error {
+	var firstErr error
+	for !until() {
+		task, ok := intr.loop.next()
+		if !ok {
+			break
+		}
+
+		if err := task(); err != nil {
+			if _, ok := err.(Exit); ok {
+				return err
+			}
+
+			intr.report(err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func newLoxPromise(loop *EventLoop) *LoxPromise {
+	return &LoxPromise{loop: loop}
+}
+
+func (promise *LoxPromise) settle(value interface{}, err error) {
+	promise.mutex.Lock()
+	defer promise.mutex.Unlock()
+
+	if promise.settled {
+		return
+	}
+
+	promise.settled = true
+	promise.outcome = promiseResult{value: value, err: err}
+	for _, callback := range promise.callbacks {
+		promise.loop.enqueue(callback)
+	}
+	promise.callbacks = nil
+
+	// rejections nobody waits for would go unnoticed, by the time this task
+	// runs the code that created the promise had the chance to await it
+	if err != nil {
+		promise.loop.enqueue(func() error {
+			promise.mutex.Lock()
+			defer promise.mutex.Unlock()
+
+			if !promise.awaited {
+				return err
+			}
+
+			return nil
+		})
+	}
+}
+
+// then queues the callback once the promise settles
+func (promise *LoxPromise) then(callback func() error) {
+	promise.mutex.Lock()
+	defer promise.mutex.Unlock()
+
+	promise.awaited = true
+	if promise.settled {
+		promise.loop.enqueue(callback)
+	} else {
+		promise.callbacks = append(promise.callbacks, callback)
+	}
+}
+
+func (promise *LoxPromise) markAwaited() {
+	promise.mutex.Lock()
+	defer promise.mutex.Unlock()
+
+	promise.awaited = true
+}
+
+func (promise *LoxPromise) isSettled() bool {
+	promise.mutex.Lock()
+	defer promise.mutex.Unlock()
+
+	return promise.settled
+}
+
+func (promise *LoxPromise) result() (interface{}, error) {
+	promise.mutex.Lock()
+	defer promise.mutex.Unlock()
+
+	return promise.outcome.value, promise.outcome.err
+}
+
+// startAsync runs the body of an async function on a coroutine until its
+// first await, the returned promise settles once the body finishes
+func (intr *Interpreter) startAsync(function FunctionLoxCallable, environment *Environment) *LoxPromise {
+	promise := newLoxPromise(intr.loop)
+	child := intr.fork()
+
+	co := newCoroutine(func(co *coroutine) (interface{}, error) {
+		child.async = co
+
+		err := child.executeBlock(function.declaration.Body, environment)
+		child.release(environment)
+		if ret, ok := err.(Return); ok {
+			return ret.Value, nil
+		}
+
+		return nil, err
+	})
+
+	stepAsync(co, promise, nil)
+	return promise
+}
+
+// stepAsync resumes the coroutine until it awaits a promise, which will step
+// it again once it settles, or until the async function returns
+func stepAsync(co *coroutine, promise *LoxPromise, value interface{}) {
+	result := co.resume(value)
+	if result.done {
+		promise.settle(result.value, result.err)
+		return
+	}
+
+	awaited := result.value.(*LoxPromise)
+	awaited.then(func() error {
+		value, err := awaited.result()
+		stepAsync(co, promise, promiseResult{value: value, err: err})
+		return nil
+	})
+}
+
+// sleep returns a promise that settles after the given milliseconds
+func sleep(intr *Interpreter, milliseconds float64) *LoxPromise {
+	promise := newLoxPromise(intr.loop)
+	intr.loop.after(time.Duration(milliseconds*float64(time.Millisecond)), func() error {
+		promise.settle(nil, nil)
+		return nil
+	})
+
+	return promise
+}
+
+// timer calls the callable from the event loop after the given milliseconds,
+// the returned promise settles with its result
+func timer(intr *Interpreter, milliseconds float64, callable LoxCallable) *LoxPromise {
+	promise := newLoxPromise(intr.loop)
+	child := intr.fork()
+
+	intr.loop.after(time.Duration(milliseconds*float64(time.Millisecond)), func() error {
+		value, err := child.call(callable, nil, Token{})
+		promise.settle(value, err)
+		return nil
+	})
+
+	return promise
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAwaitIgnoresErrorsOfOtherTasks(t *testing.T) {
+	_, stdout, stderr := runScript(t, `
+		async fun bad() {
+			return undefinedVariable;
+		}
+
+		bad();
+		var x = await sleep(20);
+		print "awaited";
+		print x;
+	`)
+
+	if stdout != "awaited\nnil\n" {
+		t.Errorf("expected the await to succeed but got %q", stdout)
+	}
+
+	if count := strings.Count(stderr, "Undefined variable 'undefinedVariable'"); count != 1 {
+		t.Errorf("expected the error of the other task to be reported once but got %q", stderr)
+	}
+}
+
+func TestAwaitFailsWithTheAwaitedPromise(t *testing.T) {
+	_, stdout, stderr := runScript(t, `
+		async fun bad() {
+			return undefinedVariable;
+		}
+
+		async fun double(n) {
+			await sleep(1);
+			return n * 2;
+		}
+
+		print await double(21);
+		var x = await bad();
+	`)
+
+	if stdout != "42\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+
+	if count := strings.Count(stderr, "Undefined variable 'undefinedVariable'"); count != 1 {
+		t.Errorf("expected the awaited error to be reported once but got %q", stderr)
+	}
+}
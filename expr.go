@@ -10,6 +10,17 @@ type ExprCall struct {
 	Arguments []Expr
 }
 
+type ExprGet struct {
+	Object Expr
+	Name   Token
+}
+
+type ExprSet struct {
+	Object Expr
+	Name   Token
+	Value  Expr
+}
+
 type ExprBinary struct {
 	Operator Token
 	Left     Expr
@@ -39,6 +50,18 @@ type ExprVariable struct {
 	Name Token
 }
 
+// ExprInterpolation is a string with embedded ${expressions}, its parts are
+// alternating string literals and expressions
+type ExprInterpolation struct {
+	Token Token
+	Parts []Expr
+}
+
+type ExprAwait struct {
+	Keyword    Token
+	Expression Expr
+}
+
 type ExprUnary struct {
 	Operator Token
 	Right    Expr
@@ -48,6 +71,14 @@ func (expr ExprCall) accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitExprCall(expr)
 }
 
+func (expr ExprGet) accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitExprGet(expr)
+}
+
+func (expr ExprSet) accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitExprSet(expr)
+}
+
 func (expr ExprLogical) accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitExprLogical(expr)
 }
@@ -75,3 +106,11 @@ func (expr ExprVariable) accept(visitor ExprVisitor) (interface{}, error) {
 func (expr ExprUnary) accept(visitor ExprVisitor) (interface{}, error) {
 	return visitor.VisitExprUnary(expr)
 }
+
+func (expr ExprAwait) accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitExprAwait(expr)
+}
+
+func (expr ExprInterpolation) accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitExprInterpolation(expr)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// LoxFile is the handle returned by open, iterating over it yields its lines
+type LoxFile struct {
+	Path string `lox:"path,readonly"`
+
+	intr   *Interpreter
+	mutex  sync.Mutex
+	file   *os.File
+	lines  *lineReader
+	closed bool
+}
+
+type loxFileIterator struct {
+	file *LoxFile
+}
+
+func init() {
+	registerNatives(CAPABILITY_IO, map[string]interface{}{
+		"readFile":   readFile,
+		"writeFile":  writeFile,
+		"appendFile": appendFile,
+		"exists":     exists,
+		"listDir":    listDir,
+		"remove":     remove,
+		"mkdir":      mkdir,
+		"open":       open,
+	})
+}
+
+// fileError reports an OS error with the path given by the script, the
+// reason alone is kept since os errors already repeat the path
+func fileError(action string, path string, err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+
+	return fmt.Errorf("Can't %v '%v': %v", action, path, err.Error())
+}
+
+func readFile(intr *Interpreter, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fileError("read", path, err)
+	}
+
+	if err := intr.reserve(Token{}, int(info.Size())); err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fileError("read", path, err)
+	}
+
+	return string(content), nil
+}
+
+func writeFile(path string, content string) error {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fileError("write", path, err)
+	}
+
+	return nil
+}
+
+func appendFile(path string, content string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fileError("append to", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		return fileError("append to", path, err)
+	}
+
+	return nil
+}
+
+func exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fileError("access", path, err)
+	}
+
+	return true, nil
+}
+
+// listDir returns the sorted names of the entries in the directory
+func listDir(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fileError("list", path, err)
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// remove deletes a file or an empty directory
+func remove(path string) error {
+	if err := os.Remove(path); err != nil {
+		return fileError("remove", path, err)
+	}
+
+	return nil
+}
+
+// mkdir creates the directory along with any missing parents
+func mkdir(path string) error {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fileError("create directory", path, err)
+	}
+
+	return nil
+}
+
+// open returns a handle to the file, mode is "r" (the default) to read, "w" to
+// truncate and write or "a" to append
+func open(intr *Interpreter, path string, mode ...string) (*LoxFile, error) {
+	flags := os.O_RDONLY
+	if len(mode) > 1 {
+		return nil, fmt.Errorf("Expected at most 2 arguments but got %v", len(mode)+1)
+	} else if len(mode) == 1 {
+		switch mode[0] {
+		case "r":
+		case "w":
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		case "a":
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		default:
+			return nil, fmt.Errorf("Unknown file mode '%v', expected \"r\", \"w\" or \"a\"", mode[0])
+		}
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fileError("open", path, err)
+	}
+
+	return &LoxFile{
+		Path:  path,
+		intr:  intr,
+		file:  file,
+		lines: newLineReader(file),
+	}, nil
+}
+
+// ReadLine returns the next line without its terminator or nil at the end
+func (file *LoxFile) ReadLine() (interface{}, error) {
+	if err := file.check("read"); err != nil {
+		return nil, err
+	}
+
+	line, ok, err := file.lines.readLine()
+	if err != nil {
+		return nil, fileError("read", file.Path, err)
+	} else if !ok {
+		return nil, nil
+	}
+
+	if err := file.intr.allocate(Token{}, len(line)); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+// Read returns the rest of the file
+func (file *LoxFile) Read() (string, error) {
+	if err := file.check("read"); err != nil {
+		return "", err
+	}
+
+	file.lines.mutex.Lock()
+	content, err := io.ReadAll(file.lines.reader)
+	file.lines.mutex.Unlock()
+
+	if err != nil {
+		return "", fileError("read", file.Path, err)
+	}
+
+	if err := file.intr.allocate(Token{}, len(content)); err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+func (file *LoxFile) Write(content string) error {
+	if err := file.check("write to"); err != nil {
+		return err
+	}
+
+	if _, err := file.file.WriteString(content); err != nil {
+		return fileError("write to", file.Path, err)
+	}
+
+	return nil
+}
+
+// Close can be called more than once
+func (file *LoxFile) Close() error {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	if file.closed {
+		return nil
+	}
+
+	file.closed = true
+	if err := file.file.Close(); err != nil {
+		return fileError("close", file.Path, err)
+	}
+
+	return nil
+}
+
+func (file *LoxFile) String() string {
+	return fmt.Sprintf("<file %v>", file.Path)
+}
+
+func (file *LoxFile) Iterator() LoxIterator {
+	return &loxFileIterator{file: file}
+}
+
+func (file *LoxFile) check(action string) error {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	if file.closed {
+		return fmt.Errorf("Can't %v '%v': file is closed", action, file.Path)
+	}
+
+	return nil
+}
+
+func (it *loxFileIterator) Next() (interface{}, bool, error) {
+	line, err := it.file.ReadLine()
+	if err != nil || line == nil {
+		return nil, false, err
+	}
+
+	return line, true, nil
+}
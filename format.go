@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Placeholders look like {[index][:[[fill]align][0][width][.precision][type]]},
+// align is one of < > ^ and type one of b o d x X e f s. Braces are escaped
+// by doubling them
+
+// Widths and precisions above this are rejected
+const FORMAT_MAX_WIDTH = 10000
+
+type formatSpec struct {
+	fill      rune
+	align     rune
+	zero      bool
+	width     int
+	precision int
+	verb      rune
+}
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"format": format,
+		"write":  write,
+	})
+}
+
+func format(intr *Interpreter, template string, arguments ...interface{}) (string, error) {
+	var sb strings.Builder
+	next := 0
+
+	for i := 0; i < len(template); i += 1 {
+		c := template[i]
+
+		if c == '}' {
+			if i+1 < len(template) && template[i+1] == '}' {
+				sb.WriteByte('}')
+				i += 1
+				continue
+			}
+
+			return "", errors.New("Unmatched '}' in format string, use '}}' to escape it")
+		}
+
+		if c != '{' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if i+1 < len(template) && template[i+1] == '{' {
+			sb.WriteByte('{')
+			i += 1
+			continue
+		}
+
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return "", errors.New("Unterminated '{' in format string")
+		}
+
+		field := template[i+1 : i+end]
+		i += end
+
+		reference, specText := field, ""
+		if colon := strings.IndexByte(field, ':'); colon >= 0 {
+			reference, specText = field[:colon], field[colon+1:]
+		}
+
+		index := next
+		if reference != "" {
+			n, err := strconv.Atoi(reference)
+			if err != nil || n < 0 {
+				return "", fmt.Errorf("Invalid placeholder '{%v}'", field)
+			}
+
+			index = n
+		} else {
+			next += 1
+		}
+
+		if index >= len(arguments) {
+			return "", fmt.Errorf("Placeholder '{%v}' refers to argument %v but got %v arguments", field, index+1, len(arguments))
+		}
+
+		spec, err := parseFormatSpec(specText)
+		if err != nil {
+			return "", err
+		}
+
+		str, err := intr.formatValue(arguments[index], spec)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(str)
+	}
+
+	return sb.String(), nil
+}
+
+func parseFormatSpec(text string) (formatSpec, error) {
+	spec := formatSpec{fill: ' ', precision: -1}
+	runes := []rune(text)
+	pos := 0
+
+	isAlign := func(r rune) bool { return r == '<' || r == '>' || r == '^' }
+
+	if len(runes) >= 2 && isAlign(runes[1]) {
+		spec.fill, spec.align = runes[0], runes[1]
+		pos = 2
+	} else if len(runes) >= 1 && isAlign(runes[0]) {
+		spec.align = runes[0]
+		pos = 1
+	}
+
+	if pos < len(runes) && runes[pos] == '0' {
+		spec.zero = true
+		pos += 1
+	}
+
+	start := pos
+	for pos < len(runes) && isDigit(runes[pos]) {
+		pos += 1
+	}
+	if pos > start {
+		width, err := parseFormatNumber(string(runes[start:pos]), "width", text)
+		if err != nil {
+			return spec, err
+		}
+
+		spec.width = width
+	}
+
+	if pos < len(runes) && runes[pos] == '.' {
+		pos += 1
+		start = pos
+		for pos < len(runes) && isDigit(runes[pos]) {
+			pos += 1
+		}
+
+		if pos == start {
+			return spec, fmt.Errorf("Expected a precision after '.' in format spec '%v'", text)
+		}
+
+		precision, err := parseFormatNumber(string(runes[start:pos]), "precision", text)
+		if err != nil {
+			return spec, err
+		}
+
+		spec.precision = precision
+	}
+
+	if pos < len(runes) && strings.ContainsRune("bdoxXefs", runes[pos]) {
+		spec.verb = runes[pos]
+		pos += 1
+	}
+
+	if pos != len(runes) {
+		return spec, fmt.Errorf("Invalid format spec '%v'", text)
+	}
+
+	return spec, nil
+}
+
+func parseFormatNumber(digits string, name string, text string) (int, error) {
+	n, err := strconv.Atoi(digits)
+	if err != nil || n > FORMAT_MAX_WIDTH {
+		return 0, fmt.Errorf("The %v of format spec '%v' can't be larger than %v", name, text, FORMAT_MAX_WIDTH)
+	}
+
+	return n, nil
+}
+
+func (intr *Interpreter) formatValue(value interface{}, spec formatSpec) (string, error) {
+	number, isNumber := value.(float64)
+
+	var str string
+	switch spec.verb {
+	case 'b', 'o', 'd', 'x', 'X':
+		if !isNumber || number != math.Trunc(number) || math.Abs(number) >= 1<<63 {
+			return "", fmt.Errorf("Format type '%c' requires an integer but got %v", spec.verb, typeName(value))
+		}
+
+		bases := map[rune]int{'b': 2, 'o': 8, 'd': 10, 'x': 16, 'X': 16}
+		str = strconv.FormatInt(int64(number), bases[spec.verb])
+		if spec.verb == 'X' {
+			str = strings.ToUpper(str)
+		}
+	case 'e', 'f':
+		if !isNumber {
+			return "", fmt.Errorf("Format type '%c' requires a number but got %v", spec.verb, typeName(value))
+		}
+
+		precision := spec.precision
+		if precision < 0 && spec.verb == 'f' {
+			precision = 6
+		}
+
+		str = strconv.FormatFloat(number, byte(spec.verb), precision, 64)
+	default:
+		if isNumber && spec.precision >= 0 && spec.verb == 0 {
+			str = strconv.FormatFloat(number, 'f', spec.precision, 64)
+			break
+		}
+
+		var err error
+		if str, err = intr.stringify(value); err != nil {
+			return "", err
+		}
+
+		if spec.precision >= 0 && utf8.RuneCountInString(str) > spec.precision {
+			str = string([]rune(str)[:spec.precision])
+		}
+	}
+
+	return intr.pad(str, spec, isNumber)
+}
+
+// pad fills the string up to the spec width, numbers are aligned right by
+// default and zero padding goes after their sign
+func (intr *Interpreter) pad(str string, spec formatSpec, isNumber bool) (string, error) {
+	missing := spec.width - utf8.RuneCountInString(str)
+	if missing <= 0 {
+		return str, nil
+	}
+
+	if err := intr.reserve(Token{}, missing*utf8.RuneLen(spec.fill)); err != nil {
+		return "", err
+	}
+
+	if spec.zero && spec.align == 0 {
+		if isNumber && (strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+")) {
+			return str[:1] + strings.Repeat("0", missing) + str[1:], nil
+		}
+
+		return strings.Repeat("0", missing) + str, nil
+	}
+
+	align := spec.align
+	if align == 0 {
+		align = '<'
+		if isNumber {
+			align = '>'
+		}
+	}
+
+	fill := string(spec.fill)
+	switch align {
+	case '>':
+		return strings.Repeat(fill, missing) + str, nil
+	case '^':
+		return strings.Repeat(fill, missing/2) + str + strings.Repeat(fill, missing-missing/2), nil
+	default:
+		return str + strings.Repeat(fill, missing), nil
+	}
+}
+
+// write prints the values like print does but without a trailing newline
+func write(intr *Interpreter, values ...interface{}) error {
+	str, err := intr.stringifyValues(values)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(intr.stdout, str)
+	return nil
+}
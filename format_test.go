@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	for source, expected := range map[string]string{
+		`format("{} + {} = {}", 1, 2, 3)`:       "1 + 2 = 3",
+		`format("{1} {0}", "a", "b")`:           "b a",
+		`format("|{:<6}|{:>8.2f}|", "pi", PI)`:  "|pi    |    3.14|",
+		`format("{:*^7}", "mid")`:               "**mid**",
+		`format("{:05d}", 0 - 42)`:              "-0042",
+		`format("{:x} {:X} {:b}", 255, 255, 5)`: "ff FF 101",
+		`format("{:.3}", "abcdef")`:             "abc",
+		`format("{{}}")`:                        "{}",
+	} {
+		_, stdout, stderr := runScript(t, "print "+source+";")
+		if stdout != expected+"\n" || stderr != "" {
+			t.Errorf("expected %v to be %q but got %q %q", source, expected, stdout, stderr)
+		}
+	}
+}
+
+func TestFormatRejectsInvalidSpecs(t *testing.T) {
+	for source, message := range map[string]string{
+		`format("{:99999999999999999999}", 1)`: "The width of format spec '99999999999999999999' can't be larger than 10000",
+		`format("{:.20000f}", 1)`:              "The precision of format spec '.20000f' can't be larger than 10000",
+		`format("{:.}", 1)`:                    "Expected a precision after '.'",
+		`format("{:d}", 1.5)`:                  "Format type 'd' requires an integer",
+		`format("{2}", 1)`:                     "refers to argument 3 but got 1 arguments",
+		`format("{")`:                          "Unterminated '{'",
+		`format("}")`:                          "Unmatched '}'",
+	} {
+		_, _, stderr := runScript(t, "print "+source+";")
+		if !strings.Contains(stderr, message) {
+			t.Errorf("expected %v to fail with %q but got %q", source, message, stderr)
+		}
+	}
+}
+
+func TestFormatPaddingIsCharged(t *testing.T) {
+	_, stdout, stderr := runScript(t, `print len(format("{:10000}", 1));`, WithMemoryLimit(5000))
+	if stdout != "" || !strings.Contains(stderr, "Memory limit exceeded") {
+		t.Errorf("expected the padding to exceed the limit but got %q %q", stdout, stderr)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LoxIterator is implemented by the values a for-in loop can iterate over
+type LoxIterator interface {
+	Next() (interface{}, bool, error)
+}
+
+type LoxIterable interface {
+	Iterator() LoxIterator
+}
+
+// LoxGenerator is returned by calling a `fun*` function, its body runs on a
+// coroutine that is suspended every time it yields a value
+type LoxGenerator struct {
+	name     string
+	co       *coroutine
+	registry *generatorRegistry
+
+	// the coroutine is only used by whoever set running, the mutex is not
+	// held while it runs so its body can't deadlock by resuming itself
+	mutex   sync.Mutex
+	running bool
+	done    bool
+}
+
+// generatorRegistry tracks the unfinished generators of an interpreter and
+// its forks, a suspended generator keeps a goroutine alive until closed
+type generatorRegistry struct {
+	mutex      sync.Mutex
+	generators map[*LoxGenerator]bool
+}
+
+func iteratorOf(value interface{}) (LoxIterator, bool) {
+	if obj, ok := value.(*GoObject); ok {
+		value = obj.value.Interface()
+	}
+
+	switch v := value.(type) {
+	case LoxIterable:
+		return v.Iterator(), true
+	case LoxIterator:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+func newLoxGenerator(intr *Interpreter, function FunctionLoxCallable, environment *Environment) *LoxGenerator {
+	child := intr.fork()
+
+	gen := &LoxGenerator{
+		name:     function.declaration.Name.Lexeme,
+		registry: intr.generators,
+		co: newCoroutine(func(co *coroutine) (interface{}, error) {
+			child.generator = co
+
+			err := child.executeBlock(function.declaration.Body, environment)
+			child.release(environment)
+			if _, ok := err.(Return); ok {
+				return nil, nil
+			}
+
+			return nil, err
+		}),
+	}
+
+	intr.generators.add(gen)
+	return gen
+}
+
+func newGeneratorRegistry() *generatorRegistry {
+	return &generatorRegistry{generators: make(map[*LoxGenerator]bool)}
+}
+
+func (registry *generatorRegistry) add(gen *LoxGenerator) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.generators[gen] = true
+}
+
+func (registry *generatorRegistry) remove(gen *LoxGenerator) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	delete(registry.generators, gen)
+}
+
+// Close stops the unfinished generators of the interpreter and its forks so
+// their goroutines exit, hosts should call it once done with the interpreter
+func (intr *Interpreter) Close() {
+	intr.generators.mutex.Lock()
+	var generators []*LoxGenerator
+	for gen := range intr.generators.generators {
+		generators = append(generators, gen)
+	}
+	intr.generators.mutex.Unlock()
+
+	for _, gen := range generators {
+		// generators still running on another task are left to finish
+		gen.Close()
+	}
+}
+
+// Next resumes the generator until it yields, the second result is false
+// once the generator finished
+func (gen *LoxGenerator) Next() (interface{}, bool, error) {
+	if err := gen.acquire(); err != nil {
+		return nil, false, err
+	}
+
+	result := gen.co.resume(nil)
+	gen.release(result.done)
+
+	if result.done {
+		return nil, false, result.err
+	}
+
+	return result.value, true, nil
+}
+
+// Close stops the generator where it is suspended, it produces no more values
+func (gen *LoxGenerator) Close() error {
+	if err := gen.acquire(); err != nil {
+		return err
+	}
+
+	gen.co.cancel()
+	gen.release(true)
+	return nil
+}
+
+func (gen *LoxGenerator) acquire() error {
+	gen.mutex.Lock()
+	defer gen.mutex.Unlock()
+
+	if gen.running {
+		return RuntimeError{Message: "Generator is already running"}
+	}
+
+	gen.running = true
+	return nil
+}
+
+func (gen *LoxGenerator) release(done bool) {
+	gen.mutex.Lock()
+	gen.running = false
+	gen.done = done
+	gen.mutex.Unlock()
+
+	if done {
+		gen.registry.remove(gen)
+	}
+}
+
+func (gen *LoxGenerator) isDone() bool {
+	gen.mutex.Lock()
+	defer gen.mutex.Unlock()
+
+	return gen.done
+}
+
+func (gen *LoxGenerator) Get(name Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "next":
+		return NewNativeLoxCallable("next", func() (interface{}, error) {
+			value, _, err := gen.Next()
+			return value, err
+		}), nil
+	case "close":
+		return NewNativeLoxCallable("close", gen.Close), nil
+	case "done":
+		return gen.isDone(), nil
+	}
+
+	return nil, RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%v'", name.Lexeme),
+	}
+}
+
+func (gen *LoxGenerator) Set(name Token, value interface{}) error {
+	return RuntimeError{
+		Token:   name,
+		Message: "Can't set properties on a generator",
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGeneratorYieldsValues(t *testing.T) {
+	_, stdout, stderr := runScript(t, `
+		fun* range(n) {
+			for (var i = 0; i < n; i = i + 1) {
+				yield i;
+			}
+		}
+
+		for (var i in range(3)) {
+			print i;
+		}
+
+		var numbers = range(2);
+		print numbers.next();
+		numbers.close();
+		print numbers.done;
+		print numbers.next();
+	`)
+
+	if expected := "0\n1\n2\n0\ntrue\nnil\n"; stdout != expected || stderr != "" {
+		t.Errorf("expected %q but got %q %q", expected, stdout, stderr)
+	}
+}
+
+func TestGeneratorCantResumeItself(t *testing.T) {
+	_, stdout, stderr := runScript(t, `
+		var g;
+		fun* gen() {
+			yield g.next();
+		}
+		g = gen();
+		g.next();
+		print "after";
+	`)
+
+	if !strings.Contains(stderr, "Generator is already running") || stdout != "after\n" {
+		t.Errorf("expected the reentrant call to fail but got %q %q", stdout, stderr)
+	}
+}
+
+func TestGeneratorCantCloseItself(t *testing.T) {
+	_, _, stderr := runScript(t, `
+		var g;
+		fun* gen() {
+			g.close();
+			yield 1;
+		}
+		g = gen();
+		g.next();
+	`)
+
+	if !strings.Contains(stderr, "Generator is already running") {
+		t.Errorf("expected closing a running generator to fail but got %q", stderr)
+	}
+}
+
+func TestGeneratorIsClosedWhenLoopIsLeftEarly(t *testing.T) {
+	program, err := Compile(`
+		fun* naturals() {
+			var i = 0;
+			while (true) {
+				yield i;
+				i = i + 1;
+			}
+		}
+
+		fun first(n) {
+			for (var x in naturals()) {
+				if (x == n) {
+					return x;
+				}
+			}
+		}
+
+		fun fail() {
+			for (var x in naturals()) {
+				print undefinedVariable;
+			}
+		}
+
+		var total = 0;
+		for (var i = 0; i < 100; i = i + 1) {
+			total = total + first(3);
+		}
+		print total;
+		fail();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not closed by the test so suspended generators can be counted
+	var stdout, stderr bytes.Buffer
+	intr := NewInterpreter(WithStdout(&stdout), WithStderr(&stderr))
+	intr.Run(program)
+
+	if stdout.String() != "300\n" || !strings.Contains(stderr.String(), "Undefined variable 'undefinedVariable'") {
+		t.Errorf("unexpected output %q %q", stdout.String(), stderr.String())
+	}
+
+	intr.generators.mutex.Lock()
+	defer intr.generators.mutex.Unlock()
+
+	if suspended := len(intr.generators.generators); suspended != 0 {
+		t.Errorf("expected every generator to be closed but %v are suspended", suspended)
+	}
+}
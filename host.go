@@ -0,0 +1,61 @@
+package main
+
+import "fmt"
+
+// Call invokes the global Lox function with the given name, Go arguments are
+// converted to Lox values and the result is converted back to a Go value
+func (intr *Interpreter) Call(name string, arguments ...interface{}) (interface{}, error) {
+	token := NewToken(IDENTIFIER, name, nil, 0)
+
+	callee, err := intr.globals.Get(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return intr.callFromHost(callee, token, arguments)
+}
+
+// CallValue invokes a Lox callable received from a script, e.g. a callback
+// passed as an argument to a native
+func (intr *Interpreter) CallValue(callee interface{}, arguments ...interface{}) (interface{}, error) {
+	return intr.callFromHost(callee, NewToken(IDENTIFIER, "callback", nil, 0), arguments)
+}
+
+// CallMethod invokes the method with the given name on a Lox object
+func (intr *Interpreter) CallMethod(object interface{}, name string, arguments ...interface{}) (interface{}, error) {
+	token := NewToken(IDENTIFIER, name, nil, 0)
+
+	obj, ok := object.(LoxObject)
+	if !ok {
+		return nil, RuntimeError{
+			Token:   token,
+			Message: fmt.Sprintf("Can't call method '%v' on %v", name, typeName(object)),
+		}
+	}
+
+	method, err := obj.Get(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return intr.callFromHost(method, token, arguments)
+}
+
+func (intr *Interpreter) callFromHost(callee interface{}, token Token, arguments []interface{}) (interface{}, error) {
+	values := make([]interface{}, len(arguments))
+	for i, arg := range arguments {
+		value, err := fromGo(arg)
+		if err != nil {
+			return nil, fmt.Errorf("Argument %v %v", i+1, err.Error())
+		}
+
+		values[i] = value
+	}
+
+	value, err := intr.call(callee, values, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return toHost(value), nil
+}
@@ -2,11 +2,12 @@ package main
 
 import (
 	"fmt"
-	"math"
+	"io"
+	"os"
+	"strings"
+	"time"
 )
 
-const EPS = 1e-9
-
 type RuntimeError struct {
 	Token   Token
 	Message string
@@ -16,6 +17,11 @@ type Return struct {
 	Value interface{}
 }
 
+// Exit unwinds the interpreter when a script calls exit(code)
+type Exit struct {
+	Code int
+}
+
 func (err RuntimeError) Error() string {
 	return err.Message
 }
@@ -24,56 +30,137 @@ func (err Return) Error() string {
 	return ""
 }
 
+func (err Exit) Error() string {
+	return fmt.Sprintf("exit status %v", err.Code)
+}
+
 type Interpreter struct {
+	builtins    *Environment
 	globals     *Environment
 	environment *Environment
+
+	// file being executed, imports are resolved relative to it
+	path       string
+	searchPath []string
+	loader     ModuleLoader
+	modules    *moduleRegistry
+	importing  []string // files being imported by this interpreter, outermost first
+
+	memoryLimit  int
+	memoryUsed   *int64
+	capabilities map[string]bool
+	disabled     map[string]bool
+
+	// arguments given to the script, see args()
+	args []string
+
+	stdout io.Writer
+	stderr io.Writer
+	stdin  *lineReader
+
+	random *randomSource
+
+	loop       *EventLoop
+	generators *generatorRegistry
+
+	// forks share the start time so ticks() is the same for every task
+	start time.Time
+
+	// set while running the body of a generator or an async function
+	generator *coroutine
+	async     *coroutine
 }
 
-func NewInterpreter() *Interpreter {
-	// global env
-	global := NewEnvironment(nil)
-	global.Define("clock", ClockLoxCallable{})
+func NewInterpreter(options ...InterpreterOption) *Interpreter {
+	intr := &Interpreter{
+		memoryUsed: new(int64),
+		loop:       NewEventLoop(),
+		generators: newGeneratorRegistry(),
+		start:      time.Now(),
+		loader:     OSModuleLoader{},
+		modules:    newModuleRegistry(),
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+		stdin:      stdinReader,
+		random:     newTimeSeededRandomSource(),
+	}
+	for _, option := range options {
+		option(intr)
+	}
 
-	return &Interpreter{
-		globals:     global,
-		environment: global,
+	// the script itself can't be imported back by its modules
+	if intr.path != "" {
+		if path, err := intr.loader.Resolve("", intr.path, nil); err == nil {
+			intr.importing = []string{path}
+		}
 	}
+
+	// global env, natives live in an enclosing environment shared with modules
+	intr.builtins = intr.defineBuiltins()
+	intr.globals = NewEnvironment(intr.builtins)
+	intr.environment = intr.globals
+
+	return intr
 }
 
+// Interpret executes the statements reporting every runtime error, the first
+// of them is returned. Calling exit() stops the script with an Exit error
 func (intr *Interpreter) Interpret(statements []Stmt) error {
+	var firstErr error
 	for _, stmt := range statements {
 		if err := intr.execute(stmt); err != nil {
-			LoxRuntimeError(err.(RuntimeError).Token, err.Error())
+			if _, ok := err.(Exit); ok {
+				return err
+			}
+
+			intr.report(err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 
-	return nil
+	return firstErr
 }
 
-func (intr *Interpreter) stringify(value interface{}) string {
-	if value == nil {
-		return "nil"
+// report writes the error to the diagnostics writer, compile errors included
+func (intr *Interpreter) report(err error) {
+	if runtimeErr, ok := err.(RuntimeError); ok {
+		fmt.Fprintf(intr.stderr, "%v\n[line %v]\n", runtimeErr.Message, runtimeErr.Token.Line)
+	} else {
+		fmt.Fprintln(intr.stderr, err.Error())
 	}
+}
 
-	if f, ok := value.(float64); ok && math.Abs(f-math.Round(f)) <= EPS {
-		return fmt.Sprintf("%v", math.Round(f))
-	}
+func (intr *Interpreter) Run(program *Program) error {
+	return intr.Interpret(program.Statements)
+}
 
-	return fmt.Sprintf("%v", value)
+// fork creates an interpreter sharing globals, configuration and memory budget
+// with this one but with its own current environment, so that it can execute
+// code on another goroutine
+func (intr *Interpreter) fork() *Interpreter {
+	child := *intr
+	child.environment = intr.globals
+	child.generator = nil
+	child.async = nil
+	return &child
 }
 
 func (intr *Interpreter) executeBlock(statements []Stmt, environment *Environment) error {
+	// natives can re-enter the interpreter (e.g. calling a Lox callback) so the
+	// previous environment must be restored no matter how the block is left
 	previous := intr.environment
 	intr.environment = environment
+	defer func() { intr.environment = previous }()
 
 	for _, stmt := range statements {
 		if err := intr.execute(stmt); err != nil {
-			intr.environment = previous
 			return err
 		}
 	}
 
-	intr.environment = previous
 	return nil
 }
 
@@ -86,6 +173,10 @@ func (intr *Interpreter) evaluate(expr Expr) (interface{}, error) {
 }
 
 func (intr *Interpreter) VisitStmtReturn(stmt StmtReturn) error {
+	if stmt.Expression == nil {
+		return Return{Value: nil}
+	}
+
 	value, err := intr.evaluate(stmt.Expression)
 	if err != nil {
 		return err
@@ -94,13 +185,34 @@ func (intr *Interpreter) VisitStmtReturn(stmt StmtReturn) error {
 	return Return{Value: value}
 }
 
+func (intr *Interpreter) VisitStmtYield(stmt StmtYield) error {
+	if intr.generator == nil {
+		return RuntimeError{
+			Token:   stmt.Keyword,
+			Message: "Can't yield outside of a generator",
+		}
+	}
+
+	var value interface{}
+	if stmt.Expression != nil {
+		var err error
+		if value, err = intr.evaluate(stmt.Expression); err != nil {
+			return err
+		}
+	}
+
+	_, err := intr.generator.yield(value)
+	return err
+}
+
 func (intr *Interpreter) VisitStmtFunction(stmt StmtFunction) error {
 	function := FunctionLoxCallable{
 		closure:     intr.environment,
 		declaration: stmt,
 	}
-	intr.environment.Define(stmt.Name.Lexeme, function)
-	return nil
+	capture(intr.environment)
+
+	return intr.define(intr.environment, stmt.Name, function)
 }
 
 func (intr *Interpreter) VisitStmtWhile(stmt StmtWhile) error {
@@ -120,6 +232,78 @@ func (intr *Interpreter) VisitStmtWhile(stmt StmtWhile) error {
 	}
 }
 
+func (intr *Interpreter) VisitStmtForIn(stmt StmtForIn) error {
+	value, err := intr.evaluate(stmt.Iterable)
+	if err != nil {
+		return err
+	}
+
+	iterator, ok := iteratorOf(value)
+	if !ok {
+		return RuntimeError{
+			Token:   stmt.Name,
+			Message: fmt.Sprintf("Can't iterate over %v", typeName(value)),
+		}
+	}
+
+	// like in JavaScript a generator is closed once the loop is left, so a
+	// return or an error doesn't leave it suspended
+	if gen, ok := iterator.(*LoxGenerator); ok {
+		defer gen.Close()
+	}
+
+	for {
+		element, ok, err := iterator.Next()
+		if err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+
+		// every iteration gets a fresh variable so closures capture its current value
+		environment, err := intr.newScope(stmt.Name, intr.environment)
+		if err != nil {
+			return err
+		}
+
+		err = intr.define(environment, stmt.Name, element)
+		if err == nil {
+			err = intr.executeBlock([]Stmt{stmt.Body}, environment)
+		}
+
+		intr.release(environment)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (intr *Interpreter) VisitStmtImport(stmt StmtImport) error {
+	module, err := intr.importModule(stmt.Path)
+	if err != nil {
+		return err
+	}
+
+	if stmt.Alias != nil {
+		if err := intr.define(intr.environment, *stmt.Alias, module); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range stmt.Names {
+		value, err := module.Get(name)
+		if err != nil {
+			return err
+		}
+
+		if err := intr.define(intr.environment, name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (intr *Interpreter) VisitStmtIf(stmt StmtIf) error {
 	value, err := intr.evaluate(stmt.Condition)
 
@@ -135,31 +319,44 @@ func (intr *Interpreter) VisitStmtIf(stmt StmtIf) error {
 }
 
 func (intr *Interpreter) VisitStmtBlock(stmt StmtBlock) error {
-	return intr.executeBlock(stmt.Statements, NewEnvironment(intr.environment))
+	environment, err := intr.newScope(stmt.Brace, intr.environment)
+	if err != nil {
+		return err
+	}
+	defer intr.release(environment)
+
+	return intr.executeBlock(stmt.Statements, environment)
 }
 
 func (intr *Interpreter) VisitStmtVarDeclaration(stmt StmtVarDeclaration) error {
+	var value interface{}
 	if stmt.Initializer != nil {
-		value, err := intr.evaluate(stmt.Initializer)
-		if err != nil {
+		var err error
+		if value, err = intr.evaluate(stmt.Initializer); err != nil {
 			return err
-		} else {
-			intr.environment.Define(stmt.Name.Lexeme, value)
 		}
-	} else {
-		intr.environment.Define(stmt.Name.Lexeme, nil)
 	}
 
-	return nil
+	return intr.define(intr.environment, stmt.Name, value)
 }
 
 func (intr *Interpreter) VisitStmtPrint(stmt StmtPrint) error {
-	value, err := intr.evaluate(stmt.Expression)
+	values := make([]interface{}, len(stmt.Expressions))
+	for i, expr := range stmt.Expressions {
+		value, err := intr.evaluate(expr)
+		if err != nil {
+			return err
+		}
+
+		values[i] = value
+	}
+
+	str, err := intr.stringifyValues(values)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%v\n", value)
+	fmt.Fprintln(intr.stdout, str)
 	return nil
 }
 
@@ -184,23 +381,149 @@ func (intr *Interpreter) VisitExprCall(expr ExprCall) (interface{}, error) {
 		arguments = append(arguments, value)
 	}
 
+	return intr.call(callee, arguments, expr.Paren)
+}
+
+func (intr *Interpreter) call(callee interface{}, arguments []interface{}, paren Token) (interface{}, error) {
 	if f, ok := callee.(LoxCallable); ok {
-		if f.Arity() == len(arguments) {
-			return f.Call(intr, arguments)
+		if f.Arity() == VARIADIC_ARITY || f.Arity() == len(arguments) {
+			value, err := f.Call(intr, arguments)
+
+			// natives don't know where they were called from so we point their errors at the call
+			if runtimeErr, ok := err.(RuntimeError); ok && runtimeErr.Token.TokenType == "" {
+				runtimeErr.Token = paren
+				return nil, runtimeErr
+			}
+
+			return value, err
 		} else {
 			return nil, RuntimeError{
-				Token:   expr.Paren,
+				Token:   paren,
 				Message: fmt.Sprintf("Expected %v arguments but got %v instead", f.Arity(), len(arguments)),
 			}
 		}
 	} else {
 		return nil, RuntimeError{
-			Token:   expr.Paren,
+			Token:   paren,
 			Message: "Can only call functions and classes",
 		}
 	}
 }
 
+func (intr *Interpreter) VisitExprGet(expr ExprGet) (interface{}, error) {
+	object, err := intr.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj, ok := object.(LoxObject); ok {
+		return obj.Get(expr.Name)
+	} else {
+		return nil, RuntimeError{
+			Token:   expr.Name,
+			Message: "Only objects have properties",
+		}
+	}
+}
+
+func (intr *Interpreter) VisitExprSet(expr ExprSet) (interface{}, error) {
+	object, err := intr.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := object.(LoxObject)
+	if !ok {
+		return nil, RuntimeError{
+			Token:   expr.Name,
+			Message: "Only objects have fields",
+		}
+	}
+
+	value, err := intr.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := obj.Set(expr.Name, value); err != nil {
+		return nil, err
+	} else {
+		return value, nil
+	}
+}
+
+func (intr *Interpreter) VisitExprAwait(expr ExprAwait) (interface{}, error) {
+	value, err := intr.evaluate(expr.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	promise, ok := value.(*LoxPromise)
+	if !ok {
+		return value, nil
+	}
+
+	if intr.async != nil {
+		// suspend the async function until the promise settles
+		resumed, cancelErr := intr.async.yield(promise)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+
+		outcome := resumed.(promiseResult)
+		value, err = outcome.value, outcome.err
+	} else {
+		// errors of other tasks are reported by the loop and don't concern
+		// this await, only the result of the promise does
+		promise.markAwaited()
+		if err := intr.runEventLoop(promise.isSettled); err != nil {
+			if _, ok := err.(Exit); ok {
+				return nil, err
+			}
+		}
+
+		if !promise.isSettled() {
+			return nil, RuntimeError{
+				Token:   expr.Keyword,
+				Message: "Awaited promise can never be settled",
+			}
+		}
+
+		value, err = promise.result()
+	}
+
+	if runtimeErr, ok := err.(RuntimeError); ok && runtimeErr.Token.TokenType == "" {
+		runtimeErr.Token = expr.Keyword
+		return nil, runtimeErr
+	}
+
+	return value, err
+}
+
+func (intr *Interpreter) VisitExprInterpolation(expr ExprInterpolation) (interface{}, error) {
+	var sb strings.Builder
+	for _, part := range expr.Parts {
+		value, err := intr.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+
+		str, err := intr.stringify(value)
+		if err != nil {
+			return nil, err
+		}
+
+		sb.WriteString(str)
+	}
+
+	value := sb.String()
+	if err := intr.allocate(expr.Token, sizeOf(value)); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
 func (intr *Interpreter) VisitExprLogical(expr ExprLogical) (interface{}, error) {
 	left, err := intr.evaluate(expr.Left)
 	if err != nil {
@@ -269,7 +592,22 @@ func (intr *Interpreter) VisitExprBinary(expr ExprBinary) (interface{}, error) {
 		_, ok1 = left.(string)
 		_, ok2 = right.(string)
 		if ok1 || ok2 {
-			return fmt.Sprintf("%v%v", left, right), nil
+			str1, err := intr.stringify(left)
+			if err != nil {
+				return nil, err
+			}
+
+			str2, err := intr.stringify(right)
+			if err != nil {
+				return nil, err
+			}
+
+			value := str1 + str2
+			if err := intr.allocate(expr.Operator, sizeOf(value)); err != nil {
+				return nil, err
+			}
+
+			return value, nil
 		}
 	// Comparisons
 	case GREATER:
@@ -352,6 +690,13 @@ func (intr *Interpreter) isTruthy(value interface{}) bool {
 }
 
 func (intr *Interpreter) isEqual(a interface{}, b interface{}) bool {
+	// the same Go value can be wrapped more than once
+	objA, okA := a.(*GoObject)
+	objB, okB := b.(*GoObject)
+	if okA && okB && objA.value.Type() == objB.value.Type() && objA.value.Type().Comparable() {
+		return objA.value.Interface() == objB.value.Interface()
+	}
+
 	return a == b
 }
 
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Nesting deeper than this is rejected so hostile input can't exhaust the stack
+const JSON_MAX_DEPTH = 1000
+
+// Longest indent for each level, in spaces or characters
+const JSON_MAX_INDENT = 10
+
+type jsonWriter struct {
+	intr    *Interpreter
+	sb      strings.Builder
+	charged int
+	indent  string
+	seen    map[interface{}]bool
+}
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"jsonParse":     jsonParse,
+		"jsonStringify": jsonStringify,
+	})
+}
+
+// jsonParse decodes objects into maps keeping the order of their keys
+func jsonParse(intr *Interpreter, text string) (interface{}, error) {
+	if err := intr.allocate(Token{}, len(text)); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	value, err := decodeJSON(decoder, 0)
+	if err != nil {
+		return nil, jsonError(decoder, err)
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Invalid JSON at offset %v: unexpected data after the value", decoder.InputOffset())
+	}
+
+	return value, nil
+}
+
+func decodeJSON(decoder *json.Decoder, depth int) (interface{}, error) {
+	if depth > JSON_MAX_DEPTH {
+		return nil, fmt.Errorf("nesting is deeper than %v levels", JSON_MAX_DEPTH)
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('['):
+		var elements []interface{}
+		for decoder.More() {
+			element, err := decodeJSON(decoder, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, element)
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return NewLoxList(elements...), nil
+	case json.Delim('{'):
+		m := NewLoxMap()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeJSON(decoder, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			m.put(key.(string), value)
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	}
+
+	// strings, numbers, booleans and null are already Lox values
+	return token, nil
+}
+
+func jsonError(decoder *json.Decoder, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("Invalid JSON: unexpected end of input")
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("Invalid JSON at offset %v: %v", syntaxErr.Offset, syntaxErr.Error())
+	}
+
+	return fmt.Errorf("Invalid JSON at offset %v: %v", decoder.InputOffset(), err.Error())
+}
+
+// jsonStringify serializes lists, maps, strings, numbers, booleans and nil,
+// indent is either a number of spaces or the string used for each level
+func jsonStringify(intr *Interpreter, value interface{}, indent ...interface{}) (string, error) {
+	writer := &jsonWriter{intr: intr, seen: make(map[interface{}]bool)}
+
+	if len(indent) > 1 {
+		return "", fmt.Errorf("Expected at most 2 arguments but got %v", len(indent)+1)
+	} else if len(indent) == 1 {
+		switch v := indent[0].(type) {
+		case nil:
+		case float64:
+			if v < 0 || v != math.Trunc(v) || v > JSON_MAX_INDENT {
+				return "", fmt.Errorf("Indent must be an integer between 0 and %v but got %v", JSON_MAX_INDENT, formatNumber(v))
+			}
+
+			writer.indent = strings.Repeat(" ", int(v))
+		case string:
+			if utf8.RuneCountInString(v) > JSON_MAX_INDENT {
+				return "", fmt.Errorf("Indent can't be longer than %v characters", JSON_MAX_INDENT)
+			}
+
+			writer.indent = v
+		default:
+			return "", fmt.Errorf("Argument 2 of 'jsonStringify' must be a number or a string but got %v", typeName(v))
+		}
+	}
+
+	if err := writer.write(value, 0); err != nil {
+		return "", err
+	}
+
+	if err := writer.grow(); err != nil {
+		return "", err
+	}
+
+	return writer.sb.String(), nil
+}
+
+func (writer *jsonWriter) write(value interface{}, depth int) error {
+	if err := writer.grow(); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		writer.sb.WriteString("null")
+	case bool:
+		writer.sb.WriteString(strconv.FormatBool(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("Can't serialize %v to JSON", formatNumber(v))
+		}
+
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			writer.sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		} else {
+			writer.sb.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	case string:
+		writer.writeString(v)
+	case *LoxList:
+		if err := writer.enter(v); err != nil {
+			return err
+		}
+		defer delete(writer.seen, v)
+
+		elements := v.Elements()
+		writer.sb.WriteByte('[')
+		for i, element := range elements {
+			writer.separator(i, depth+1)
+			if err := writer.write(element, depth+1); err != nil {
+				return err
+			}
+		}
+		writer.close(']', len(elements), depth)
+	case *LoxMap:
+		if err := writer.enter(v); err != nil {
+			return err
+		}
+		defer delete(writer.seen, v)
+
+		keys := v.Keys()
+		writer.sb.WriteByte('{')
+		for i, key := range keys {
+			str, ok := key.(string)
+			if !ok {
+				return fmt.Errorf("Can't serialize a map to JSON, keys must be strings but got %v", typeName(key))
+			}
+
+			writer.separator(i, depth+1)
+			writer.writeString(str)
+			writer.sb.WriteByte(':')
+			if writer.indent != "" {
+				writer.sb.WriteByte(' ')
+			}
+
+			element, _ := v.Lookup(key)
+			if err := writer.write(element, depth+1); err != nil {
+				return err
+			}
+		}
+		writer.close('}', len(keys), depth)
+	default:
+		return fmt.Errorf("Can't serialize %v to JSON", typeName(value))
+	}
+
+	return nil
+}
+
+// grow charges what was written since the last call so a huge output fails
+// while it is being built
+func (writer *jsonWriter) grow() error {
+	size := writer.sb.Len()
+	if size > MAX_ALLOCATION {
+		return errTooLarge(Token{})
+	}
+
+	if err := writer.intr.allocate(Token{}, size-writer.charged); err != nil {
+		return err
+	}
+
+	writer.charged = size
+	return nil
+}
+
+func (writer *jsonWriter) enter(collection interface{}) error {
+	if writer.seen[collection] {
+		return errors.New("Can't serialize a cyclic structure to JSON")
+	}
+
+	writer.seen[collection] = true
+	return nil
+}
+
+func (writer *jsonWriter) writeString(s string) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+
+	writer.sb.Write(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")))
+}
+
+// separator goes before every element of a collection
+func (writer *jsonWriter) separator(index int, depth int) {
+	if index > 0 {
+		writer.sb.WriteByte(',')
+	}
+
+	writer.newline(depth)
+}
+
+func (writer *jsonWriter) close(delimiter byte, length int, depth int) {
+	if length > 0 {
+		writer.newline(depth)
+	}
+
+	writer.sb.WriteByte(delimiter)
+}
+
+func (writer *jsonWriter) newline(depth int) {
+	if writer.indent != "" {
+		writer.sb.WriteByte('\n')
+		writer.sb.WriteString(strings.Repeat(writer.indent, depth))
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// LoxList is an ordered collection of Lox values, lists can be shared between
+// goroutines so every access is synchronized
+type LoxList struct {
+	mutex    sync.RWMutex
+	elements []interface{}
+
+	// whether the elements were charged against the memory limit
+	charged bool
+}
+
+type loxListIterator struct {
+	elements []interface{}
+	index    int
+}
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"list": NewLoxList,
+	})
+}
+
+func NewLoxList(elements ...interface{}) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+// markCharged returns false if the list was already charged
+func (list *LoxList) markCharged() bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	charged := list.charged
+	list.charged = true
+	return !charged
+}
+
+func (list *LoxList) Len() int {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return len(list.elements)
+}
+
+// Elements returns a copy of the values in the list
+func (list *LoxList) Elements() []interface{} {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	return append([]interface{}(nil), list.elements...)
+}
+
+func (list *LoxList) GetAt(index float64) (interface{}, error) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	i, err := list.index(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.elements[i], nil
+}
+
+func (list *LoxList) SetAt(index float64, value interface{}) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	i, err := list.index(index)
+	if err != nil {
+		return err
+	}
+
+	list.elements[i] = value
+	return nil
+}
+
+func (list *LoxList) Push(intr *Interpreter, value interface{}) error {
+	if err := intr.allocate(Token{}, sizeOf(value)); err != nil {
+		return err
+	}
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	list.elements = append(list.elements, value)
+	return nil
+}
+
+func (list *LoxList) Pop() (interface{}, error) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if len(list.elements) == 0 {
+		return nil, errors.New("Can't pop from an empty list")
+	}
+
+	value := list.elements[len(list.elements)-1]
+	list.elements = list.elements[:len(list.elements)-1]
+	return value, nil
+}
+
+func (list *LoxList) index(index float64) (int, error) {
+	if index != math.Trunc(index) || index < 0 || int(index) >= len(list.elements) {
+		return 0, fmt.Errorf("Index %v out of range for list of length %v", index, len(list.elements))
+	}
+
+	return int(index), nil
+}
+
+func (list *LoxList) Iterator() LoxIterator {
+	return &loxListIterator{elements: list.Elements()}
+}
+
+func (it *loxListIterator) Next() (interface{}, bool, error) {
+	if it.index >= len(it.elements) {
+		return nil, false, nil
+	}
+
+	it.index += 1
+	return it.elements[it.index-1], true, nil
+}
+
+func (list *LoxList) Get(name Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "length":
+		return float64(list.Len()), nil
+	case "get":
+		return NewNativeLoxCallable("get", list.GetAt), nil
+	case "set":
+		return NewNativeLoxCallable("set", list.SetAt), nil
+	case "push":
+		return NewNativeLoxCallable("push", list.Push), nil
+	case "pop":
+		return NewNativeLoxCallable("pop", list.Pop), nil
+	}
+
+	return nil, RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%v'", name.Lexeme),
+	}
+}
+
+func (list *LoxList) Set(name Token, value interface{}) error {
+	return RuntimeError{
+		Token:   name,
+		Message: "Can't set properties on a list",
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -10,25 +9,25 @@ import (
 
 var hadError = false
 var hadRuntimeError = false
-var interpreter = NewInterpreter()
 
+// Usage: glox [script [arguments...]]
 func main() {
-	if len(os.Args) > 2 {
-		fmt.Println("Usage: glox [script]")
-	} else if len(os.Args) == 2 {
-		runFile(os.Args[1])
+	if len(os.Args) >= 2 {
+		runFile(os.Args[1], os.Args[2:])
 	} else {
 		runPrompt()
 	}
 }
 
-func runFile(path string) {
+func runFile(path string, args []string) {
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	run(string(content))
+	interpreter := NewInterpreter(WithPath(path), WithArgs(args...))
+	run(interpreter, string(content))
+	interpreter.Close()
 
 	if hadError {
 		os.Exit(65)
@@ -40,19 +39,32 @@ func runFile(path string) {
 }
 
 func runPrompt() {
-	reader := bufio.NewScanner(os.Stdin)
+	// scripts calling input() read from the same buffered stdin as the prompt
+	interpreter := NewInterpreter()
+
+	for {
+		line, ok, err := stdinReader.readLine()
+		if err != nil {
+			log.Fatal(err)
+		} else if !ok {
+			break
+		}
 
-	for reader.Scan() {
 		fmt.Print("> ")
-		run(reader.Text())
+		run(interpreter, line)
 		hadError = false
 		hadRuntimeError = false
 	}
 }
 
-func run(content string) {
+func run(interpreter *Interpreter, content string) {
 	scanner := NewScanner(content)
 	tokens := scanner.ScanTokens()
+	if len(scanner.Errors) > 0 {
+		interpreter.report(CompileError{Errors: scanner.Errors})
+		hadError = true
+		return
+	}
 
 	fmt.Println("--- BEGIN TOKENS --- ")
 	for _, token := range tokens {
@@ -61,8 +73,10 @@ func run(content string) {
 	fmt.Println("---- END TOKENS ---- ")
 
 	parser := NewParser(tokens)
-	program, _ := parser.Parse()
-	if hadError {
+	program, err := parser.Parse()
+	if err != nil {
+		interpreter.report(err)
+		hadError = true
 		return
 	}
 
@@ -70,28 +84,14 @@ func run(content string) {
 	fmt.Println((&AstPrinter{}).print(program))
 	fmt.Println("---- END AST ----")
 
-	interpreter.Interpret(program)
-}
-
-func LoxError(line int, message string) {
-	Report(line, "", message)
+	handleRuntimeError(interpreter.Interpret(program))
+	handleRuntimeError(interpreter.RunEventLoop())
 }
 
-func LoxTokenError(token Token, message string) {
-	if token.TokenType == EOF {
-		Report(token.Line, "at end", message)
-	} else {
-		Report(token.Line, fmt.Sprintf("at '%v'", token.Lexeme), message)
+func handleRuntimeError(err error) {
+	if exit, ok := err.(Exit); ok {
+		os.Exit(exit.Code)
+	} else if err != nil {
+		hadRuntimeError = true
 	}
 }
-
-func LoxRuntimeError(token Token, message string) {
-	log.Println(message)
-	log.Printf("[line %v]", token.Line)
-	hadRuntimeError = true
-}
-
-func Report(line int, where string, message string) {
-	log.Printf("[line %v] Error %v: %v\n", line, where, message)
-	hadError = true
-}
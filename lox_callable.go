@@ -1,6 +1,7 @@
 package main
 
-import "time"
+// Arity of callables that validate their number of arguments themselves
+const VARIADIC_ARITY = -1
 
 type LoxCallable interface {
 	Arity() int
@@ -12,28 +13,41 @@ type FunctionLoxCallable struct {
 	declaration StmtFunction
 }
 
-type ClockLoxCallable struct{}
-
 // Arity
 
 func (lc FunctionLoxCallable) Arity() int {
 	return len(lc.declaration.Parameters)
 }
 
-func (lc ClockLoxCallable) Arity() int {
-	return 0
-}
-
 // Call
 
 func (lc FunctionLoxCallable) Call(intr *Interpreter, arguments []interface{}) (interface{}, error) {
-	environment := NewEnvironment(lc.closure)
+	environment, err := intr.newScope(lc.declaration.Name, lc.closure)
+	if err != nil {
+		return nil, err
+	}
 
 	for i := 0; i < len(arguments); i += 1 {
-		environment.Define(lc.declaration.Parameters[i].Lexeme, arguments[i])
+		if err := intr.define(environment, lc.declaration.Parameters[i], arguments[i]); err != nil {
+			intr.release(environment)
+			return nil, err
+		}
 	}
 
-	err := intr.executeBlock(lc.declaration.Body, environment)
+	if lc.declaration.IsGenerator {
+		return newLoxGenerator(intr, lc, environment), nil
+	} else if lc.declaration.IsAsync {
+		return intr.startAsync(lc, environment), nil
+	}
+
+	// yield only applies to the body of the generator itself
+	generator := intr.generator
+	intr.generator = nil
+	defer func() { intr.generator = generator }()
+
+	defer intr.release(environment)
+
+	err = intr.executeBlock(lc.declaration.Body, environment)
 	if err == nil {
 		return nil, nil
 	} else if ret, ok := err.(Return); ok {
@@ -42,7 +56,3 @@ func (lc FunctionLoxCallable) Call(intr *Interpreter, arguments []interface{}) (
 		return nil, err
 	}
 }
-
-func (lc ClockLoxCallable) Call(intr *Interpreter, arguments []interface{}) (interface{}, error) {
-	return float64(time.Now().Unix()), nil
-}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LoxObject is implemented by every value whose properties can be accessed
+// with the dot operator
+type LoxObject interface {
+	Get(name Token) (interface{}, error)
+	Set(name Token, value interface{}) error
+}
+
+// GoObject exposes the exported fields and methods of a Go value to Lox, fields
+// can be renamed or made read-only with a `lox:"name,readonly"` tag and hidden
+// with `lox:"-"`, methods can be called either as obj.Method or obj.method
+type GoObject struct {
+	value reflect.Value
+}
+
+type goField struct {
+	index    []int
+	readOnly bool
+}
+
+type goObjectType struct {
+	fields  map[string]goField
+	methods map[string]string
+}
+
+// Property tables are computed once per Go type
+var goObjectTypes sync.Map
+
+func NewGoObject(value interface{}) *GoObject {
+	return &GoObject{value: reflect.ValueOf(value)}
+}
+
+// DefineObject makes a Go value available to scripts and their modules, pass a
+// pointer so that writes from Lox are visible to the host
+func (intr *Interpreter) DefineObject(name string, value interface{}) {
+	intr.builtins.Define(name, NewGoObject(value))
+}
+
+func (obj *GoObject) Get(name Token) (interface{}, error) {
+	info := obj.info()
+
+	if field, ok := info.fields[name.Lexeme]; ok {
+		value := obj.structValue().FieldByIndex(field.index)
+		if value.Kind() == reflect.Struct && value.CanAddr() {
+			return NewGoObject(value.Addr().Interface()), nil
+		}
+
+		converted, err := fromGo(value.Interface())
+		if err != nil {
+			return nil, RuntimeError{
+				Token:   name,
+				Message: fmt.Sprintf("Property '%v' can't be used from Lox: %v", name.Lexeme, err.Error()),
+			}
+		}
+
+		return converted, nil
+	}
+
+	if method, ok := info.methods[name.Lexeme]; ok {
+		return NewNativeLoxCallable(name.Lexeme, obj.value.MethodByName(method).Interface()), nil
+	}
+
+	return nil, RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%v'", name.Lexeme),
+	}
+}
+
+func (obj *GoObject) Set(name Token, value interface{}) error {
+	field, ok := obj.info().fields[name.Lexeme]
+	if !ok {
+		return RuntimeError{
+			Token:   name,
+			Message: fmt.Sprintf("Undefined property '%v'", name.Lexeme),
+		}
+	}
+
+	target := obj.structValue().FieldByIndex(field.index)
+	if field.readOnly || !target.CanSet() {
+		return RuntimeError{
+			Token:   name,
+			Message: fmt.Sprintf("Property '%v' is read-only", name.Lexeme),
+		}
+	}
+
+	converted, err := toGo(value, target.Type())
+	if err != nil {
+		return RuntimeError{
+			Token:   name,
+			Message: fmt.Sprintf("Property '%v' %v", name.Lexeme, err.Error()),
+		}
+	}
+
+	target.Set(converted)
+	return nil
+}
+
+// structValue is the struct behind the object, if any
+func (obj *GoObject) structValue() reflect.Value {
+	value := obj.value
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+
+	return value
+}
+
+func (obj *GoObject) info() goObjectType {
+	if info, ok := goObjectTypes.Load(obj.value.Type()); ok {
+		return info.(goObjectType)
+	}
+
+	info := goObjectType{
+		fields:  make(map[string]goField),
+		methods: make(map[string]string),
+	}
+
+	if structType := obj.structValue().Type(); structType.Kind() == reflect.Struct {
+		for i := 0; i < structType.NumField(); i += 1 {
+			field := structType.Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+
+			name, readOnly := field.Name, false
+			if tag, ok := field.Tag.Lookup("lox"); ok {
+				if tag == "-" {
+					continue
+				}
+
+				parts := strings.Split(tag, ",")
+				if parts[0] != "" {
+					name = parts[0]
+				}
+
+				for _, option := range parts[1:] {
+					if option == "readonly" {
+						readOnly = true
+					}
+				}
+			}
+
+			info.fields[name] = goField{
+				index:    field.Index,
+				readOnly: readOnly,
+			}
+		}
+	}
+
+	valueType := obj.value.Type()
+	for i := 0; i < valueType.NumMethod(); i += 1 {
+		// methods Lox can't call are not exposed
+		if !hasNativeResults(valueType.Method(i).Type) {
+			continue
+		}
+
+		method := valueType.Method(i).Name
+		info.methods[method] = method
+
+		runes := []rune(method)
+		runes[0] = unicode.ToLower(runes[0])
+		if alias := string(runes); info.methods[alias] == "" {
+			info.methods[alias] = method
+		}
+	}
+
+	// fields take precedence over methods with the same name
+	for name := range info.fields {
+		delete(info.methods, name)
+	}
+
+	goObjectTypes.Store(valueType, info)
+	return info
+}
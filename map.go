@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// LoxMap associates keys with values remembering the order keys were first
+// inserted in, keys can be strings, numbers or booleans
+type LoxMap struct {
+	mutex  sync.RWMutex
+	keys   []interface{}
+	values map[interface{}]interface{}
+
+	// whether the entries were charged against the memory limit
+	charged bool
+}
+
+type loxMapIterator struct {
+	keys  []interface{}
+	index int
+}
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"map": newLoxMapFromPairs,
+	})
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{values: make(map[interface{}]interface{})}
+}
+
+// newLoxMapFromPairs builds a map from alternating keys and values, it is
+// charged as a whole once returned
+func newLoxMapFromPairs(pairs ...interface{}) (*LoxMap, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("Expected pairs of keys and values but got %v arguments", len(pairs))
+	}
+
+	m := NewLoxMap()
+	for i := 0; i < len(pairs); i += 2 {
+		if err := checkMapKey(pairs[i]); err != nil {
+			return nil, err
+		}
+
+		m.put(pairs[i], pairs[i+1])
+	}
+
+	return m, nil
+}
+
+func checkMapKey(key interface{}) error {
+	switch k := key.(type) {
+	case string, bool:
+		return nil
+	case float64:
+		if math.IsNaN(k) {
+			return fmt.Errorf("Map keys can't be NaN")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("Map keys must be strings, numbers or booleans but got %v", typeName(key))
+	}
+}
+
+// markCharged returns false if the map was already charged
+func (m *LoxMap) markCharged() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	charged := m.charged
+	m.charged = true
+	return !charged
+}
+
+func (m *LoxMap) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.keys)
+}
+
+// Keys returns a copy of the keys in insertion order
+func (m *LoxMap) Keys() []interface{} {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]interface{}(nil), m.keys...)
+}
+
+// Values returns a copy of the values in the order of their keys
+func (m *LoxMap) Values() []interface{} {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	values := make([]interface{}, len(m.keys))
+	for i, key := range m.keys {
+		values[i] = m.values[key]
+	}
+
+	return values
+}
+
+// Lookup returns the value of the key and whether it is present
+func (m *LoxMap) Lookup(key interface{}) (interface{}, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// GetKey returns nil when the key is missing
+func (m *LoxMap) GetKey(key interface{}) (interface{}, error) {
+	if err := checkMapKey(key); err != nil {
+		return nil, err
+	}
+
+	value, _ := m.Lookup(key)
+	return value, nil
+}
+
+func (m *LoxMap) Has(key interface{}) (bool, error) {
+	if err := checkMapKey(key); err != nil {
+		return false, err
+	}
+
+	_, ok := m.Lookup(key)
+	return ok, nil
+}
+
+// Put sets the value of the key, new keys go after the existing ones
+func (m *LoxMap) Put(intr *Interpreter, key interface{}, value interface{}) error {
+	if err := checkMapKey(key); err != nil {
+		return err
+	}
+
+	if err := intr.allocate(Token{}, sizeOf(key)+sizeOf(value)); err != nil {
+		return err
+	}
+
+	m.put(key, value)
+	return nil
+}
+
+// put skips the key check and the memory accounting
+func (m *LoxMap) put(key interface{}, value interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+// Remove deletes the key and returns its value, or nil if it was missing
+func (m *LoxMap) Remove(key interface{}) (interface{}, error) {
+	if err := checkMapKey(key); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	value, ok := m.values[key]
+	if !ok {
+		return nil, nil
+	}
+
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+
+	return value, nil
+}
+
+// Iterating over a map yields its keys
+func (m *LoxMap) Iterator() LoxIterator {
+	return &loxMapIterator{keys: m.Keys()}
+}
+
+func (it *loxMapIterator) Next() (interface{}, bool, error) {
+	if it.index >= len(it.keys) {
+		return nil, false, nil
+	}
+
+	it.index += 1
+	return it.keys[it.index-1], true, nil
+}
+
+func (m *LoxMap) Get(name Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "length":
+		return float64(m.Len()), nil
+	case "get":
+		return NewNativeLoxCallable("get", m.GetKey), nil
+	case "set":
+		return NewNativeLoxCallable("set", m.Put), nil
+	case "has":
+		return NewNativeLoxCallable("has", m.Has), nil
+	case "remove":
+		return NewNativeLoxCallable("remove", m.Remove), nil
+	case "keys":
+		return NewNativeLoxCallable("keys", func() *LoxList { return NewLoxList(m.Keys()...) }), nil
+	case "values":
+		return NewNativeLoxCallable("values", func() *LoxList { return NewLoxList(m.Values()...) }), nil
+	}
+
+	return nil, RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%v'", name.Lexeme),
+	}
+}
+
+func (m *LoxMap) Set(name Token, value interface{}) error {
+	return RuntimeError{
+		Token:   name,
+		Message: "Can't set properties on a map, use set(key, value)",
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "math"
+
+func init() {
+	registerNatives(CAPABILITY_MATH, map[string]interface{}{
+		"sqrt":     math.Sqrt,
+		"pow":      math.Pow,
+		"abs":      math.Abs,
+		"floor":    math.Floor,
+		"ceil":     math.Ceil,
+		"round":    math.Round,
+		"min":      minimum,
+		"max":      maximum,
+		"sin":      math.Sin,
+		"cos":      math.Cos,
+		"tan":      math.Tan,
+		"asin":     math.Asin,
+		"acos":     math.Acos,
+		"atan":     math.Atan,
+		"atan2":    math.Atan2,
+		"exp":      math.Exp,
+		"log":      math.Log,
+		"log2":     math.Log2,
+		"log10":    math.Log10,
+		"isNan":    math.IsNaN,
+		"isFinite": isFinite,
+	})
+
+	// constants can't do any harm so they don't need the math capability
+	constants := capabilities[CAPABILITY_CORE]
+	constants["PI"] = math.Pi
+	constants["E"] = math.E
+	constants["INF"] = math.Inf(1)
+	constants["NAN"] = math.NaN()
+}
+
+func minimum(first float64, rest ...float64) float64 {
+	result := first
+	for _, value := range rest {
+		result = math.Min(result, value)
+	}
+
+	return result
+}
+
+func maximum(first float64, rest ...float64) float64 {
+	result := first
+	for _, value := range rest {
+		result = math.Max(result, value)
+	}
+
+	return result
+}
+
+func isFinite(value float64) bool {
+	return !math.IsInf(value, 0) && !math.IsNaN(value)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Approximate sizes (in bytes) charged against the memory limit
+const (
+	VALUE_SIZE       = 16
+	ENVIRONMENT_SIZE = 64
+)
+
+// Largest buffer a native may build at once, it applies even without a
+// memory limit so a single call can't exhaust the host
+const MAX_ALLOCATION = 1 << 30
+
+// sizeOf is the cost of storing the value, collections only count as a
+// reference since their elements are charged once by chargeNew and then as
+// they grow
+func sizeOf(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return VALUE_SIZE + len(v)
+	default:
+		return VALUE_SIZE
+	}
+}
+
+// newSize is the cost of a value created by a native, collections are counted
+// in full the first time they are seen and as a reference afterwards, which
+// also stops at cycles
+func newSize(value interface{}) int {
+	switch v := value.(type) {
+	case *LoxList:
+		if !v.markCharged() {
+			return VALUE_SIZE
+		}
+
+		size := VALUE_SIZE
+		for _, element := range v.Elements() {
+			size += newSize(element)
+		}
+
+		return size
+	case *LoxMap:
+		if !v.markCharged() {
+			return VALUE_SIZE
+		}
+
+		size := VALUE_SIZE
+		for _, key := range v.Keys() {
+			value, _ := v.Lookup(key)
+			size += newSize(key) + newSize(value)
+		}
+
+		return size
+	default:
+		return sizeOf(value)
+	}
+}
+
+// chargeNew charges a value returned by a native, only strings and
+// collections are charged like when the interpreter creates them
+func (intr *Interpreter) chargeNew(token Token, value interface{}) error {
+	if intr.memoryLimit <= 0 {
+		return nil
+	}
+
+	switch value.(type) {
+	case string, *LoxList, *LoxMap:
+		return intr.allocate(token, newSize(value))
+	}
+
+	return nil
+}
+
+// newScope creates and charges the environment of a block, loop iteration or
+// call
+func (intr *Interpreter) newScope(token Token, enclosing *Environment) (*Environment, error) {
+	if err := intr.allocate(token, ENVIRONMENT_SIZE); err != nil {
+		return nil, err
+	}
+
+	environment := NewEnvironment(enclosing)
+	environment.size = ENVIRONMENT_SIZE
+	return environment, nil
+}
+
+// define declares a variable charging its slot, redeclaring a variable of the
+// same scope reuses it
+func (intr *Interpreter) define(environment *Environment, token Token, value interface{}) error {
+	environment.mutex.Lock()
+	defer environment.mutex.Unlock()
+
+	if _, ok := environment.Values[token.Lexeme]; !ok {
+		if err := intr.allocate(token, VALUE_SIZE); err != nil {
+			return err
+		}
+
+		environment.size += VALUE_SIZE
+	}
+
+	environment.Values[token.Lexeme] = value
+	return nil
+}
+
+// release gives back what the scope was charged once it is left
+func (intr *Interpreter) release(environment *Environment) {
+	environment.mutex.Lock()
+	defer environment.mutex.Unlock()
+
+	if !environment.captured {
+		intr.free(int(environment.size))
+		environment.size = 0
+	}
+}
+
+// free gives back bytes that were charged, e.g. reserved but not used
+func (intr *Interpreter) free(size int) {
+	if intr.memoryLimit > 0 {
+		atomic.AddInt64(intr.memoryUsed, -int64(size))
+	}
+}
+
+// capture keeps the environment and its enclosing ones charged since a
+// closure may use them after they are left
+func capture(environment *Environment) {
+	for ; environment != nil; environment = environment.Enclosing {
+		environment.mutex.Lock()
+		captured := environment.captured
+		environment.captured = true
+		environment.mutex.Unlock()
+
+		if captured {
+			return
+		}
+	}
+}
+
+// reserve checks and charges a buffer of size bytes before it is allocated
+func (intr *Interpreter) reserve(token Token, size int) error {
+	if size < 0 || size > MAX_ALLOCATION {
+		return errTooLarge(token)
+	}
+
+	return intr.allocate(token, size)
+}
+
+func errTooLarge(token Token) error {
+	return RuntimeError{
+		Token:   token,
+		Message: fmt.Sprintf("Can't allocate more than %v bytes at once", MAX_ALLOCATION),
+	}
+}
+
+// allocate charges size bytes to the interpreter. The limit is an allocation
+// budget rather than a cap on live memory: values are charged when created
+// and never given back, only the environments of scopes that were left are
+// refunded
+func (intr *Interpreter) allocate(token Token, size int) error {
+	if intr.memoryLimit <= 0 {
+		return nil
+	}
+
+	// a rejected charge must not be counted, other tasks may be charging at
+	// the same time so the total is only updated if it didn't change
+	for {
+		used := atomic.LoadInt64(intr.memoryUsed)
+		if used+int64(size) > int64(intr.memoryLimit) {
+			return RuntimeError{
+				Token:   token,
+				Message: "Memory limit exceeded",
+			}
+		}
+
+		if atomic.CompareAndSwapInt64(intr.memoryUsed, used, used+int64(size)) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoryLimitRejectsChargesWithoutKeepingThem(t *testing.T) {
+	_, stdout, stderr := runScript(t, `
+		var big = repeat("x", 2000000);
+		print "a" + "b";
+	`, WithMemoryLimit(1000000))
+
+	if !strings.Contains(stderr, "Memory limit exceeded") {
+		t.Errorf("expected the large string to be rejected but got %q", stderr)
+	}
+
+	if stdout != "ab\n" {
+		t.Errorf("expected later allocations to succeed but got %q", stdout)
+	}
+}
+
+func TestMemoryLimitRefundsScopes(t *testing.T) {
+	intr, stdout, stderr := runScript(t, `
+		fun add(a, b) {
+			var sum = a + b;
+			return sum;
+		}
+
+		var total = 0;
+		for (var i = 0; i < 100000; i = i + 1) {
+			var x = 1;
+			total = add(total, x);
+		}
+
+		var numbers = list(1, 2, 3);
+		for (var n in numbers) {
+			var y = n;
+		}
+
+		print total;
+	`, WithMemoryLimit(1000000))
+
+	if stderr != "" || stdout != "100000\n" {
+		t.Fatalf("expected the loop to fit in the limit but got %q %q", stdout, stderr)
+	}
+
+	// only the globals and the list are still charged
+	if used := atomic.LoadInt64(intr.memoryUsed); used > 1000 {
+		t.Errorf("expected scopes to be refunded but %v bytes are still used", used)
+	}
+}
+
+func TestMemoryLimitKeepsCapturedScopes(t *testing.T) {
+	intr, _, stderr := runScript(t, `
+		fun counter() {
+			var count = 0;
+			fun increment() {
+				count = count + 1;
+				return count;
+			}
+			return increment;
+		}
+
+		var counters = list();
+		for (var i = 0; i < 100; i = i + 1) {
+			counters.push(counter());
+		}
+	`, WithMemoryLimit(1000000))
+
+	if stderr != "" {
+		t.Fatal(stderr)
+	}
+
+	if used := atomic.LoadInt64(intr.memoryUsed); used < 100*ENVIRONMENT_SIZE {
+		t.Errorf("expected captured scopes to stay charged but only %v bytes are used", used)
+	}
+}
+
+func TestMemoryLimitChargesAliasesAsReferences(t *testing.T) {
+	_, stdout, stderr := runScript(t, `
+		var m = map();
+		m.set("self", m);
+		var alias = m;
+		print alias;
+	`, WithMemoryLimit(1000000))
+
+	if stderr != "" || stdout != "{\"self\": {...}}\n" {
+		t.Errorf("unexpected output %q %q", stdout, stderr)
+	}
+}
+
+func TestMemoryLimitBoundsStringNatives(t *testing.T) {
+	for _, source := range []string{
+		`print len(replace(repeat("a", 5000), "a", repeat("b", 1000000)));`,
+		`print len(replace(repeat("a", 5000), "", repeat("b", 2000)));`,
+		`
+			var parts = list();
+			var part = repeat("x", 100000);
+			for (var i = 0; i < 100; i = i + 1) {
+				parts.push(part);
+			}
+			print len(join(parts, ""));
+		`,
+	} {
+		_, stdout, stderr := runScript(t, source, WithMemoryLimit(5000000))
+		if stdout != "" || stderr == "" {
+			t.Errorf("expected %q to be rejected but got %q %q", source, stdout, stderr)
+		}
+	}
+
+	_, stdout, stderr := runScript(t, `print replace("a-b-c", "-", ", ") + " " + join(list(1, 2), "+");`, WithMemoryLimit(5000000))
+	if stdout != "a, b, c 1+2\n" {
+		t.Errorf("unexpected output %q %q", stdout, stderr)
+	}
+}
+
+func TestMemoryLimitBoundsJSON(t *testing.T) {
+	deep := `
+		var value = list();
+		for (var i = 0; i < 1000; i = i + 1) {
+			value = list(value);
+		}
+	`
+
+	_, stdout, stderr := runScript(t, deep+`print len(jsonStringify(value, repeat(" ", 100000)));`, WithMemoryLimit(5000000))
+	if stdout != "" || !strings.Contains(stderr, "Indent can't be longer than 10 characters") {
+		t.Errorf("expected the indent to be rejected but got %q %q", stdout, stderr)
+	}
+
+	_, stdout, stderr = runScript(t, deep+`print len(jsonStringify(value, "\t\t\t\t\t\t\t\t\t\t"));`, WithMemoryLimit(5000000))
+	if stdout != "" || !strings.Contains(stderr, "Memory limit exceeded") {
+		t.Errorf("expected the output to exceed the limit but got %q %q", stdout, stderr)
+	}
+
+	_, stdout, stderr = runScript(t, deep+`print len(jsonStringify(value, 2));`, WithMemoryLimit(5000000))
+	if stdout != "2004002\n" {
+		t.Errorf("unexpected output %q %q", stdout, stderr)
+	}
+}
+
+func TestMemoryLimitBoundsRegexReplace(t *testing.T) {
+	_, stdout, stderr := runScript(t, `print len(regex("a").replace(repeat("a", 5000), repeat("b", 1000000)));`, WithMemoryLimit(5000000))
+	if stdout != "" || stderr == "" {
+		t.Errorf("expected the replacement to be rejected but got %q %q", stdout, stderr)
+	}
+
+	intr, stdout, stderr := runScript(t, `print regex("(\\w+)@(\\w+)").replace("me@home you@work", "$2:$1");`, WithMemoryLimit(5000000))
+	if stdout != "home:me work:you\n" {
+		t.Errorf("unexpected output %q %q", stdout, stderr)
+	}
+
+	// only the result is left charged
+	if used := atomic.LoadInt64(intr.memoryUsed); used > 100 {
+		t.Errorf("expected the unused reservation to be given back but %v bytes are used", used)
+	}
+}
+
+func TestMemoryLimitBoundsChannelBuffers(t *testing.T) {
+	for _, options := range [][]InterpreterOption{{WithMemoryLimit(1000000)}, nil} {
+		_, _, stderr := runScript(t, `var c = channel(1000000000000);`, options...)
+		if !strings.Contains(stderr, "Can't allocate more than") {
+			t.Errorf("expected the channel to be rejected but got %q", stderr)
+		}
+	}
+
+	_, stdout, stderr := runScript(t, `var c = channel(10); send(c, 1); print recv(c);`, WithMemoryLimit(1000000))
+	if stdout != "1\n" {
+		t.Errorf("unexpected output %q %q", stdout, stderr)
+	}
+}
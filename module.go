@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LoxModule is the namespace holding the top-level globals of an imported file
+type LoxModule struct {
+	path    string
+	globals *Environment
+}
+
+// moduleRegistry is shared by every interpreter of a program so each module
+// is executed only once
+type moduleRegistry struct {
+	mutex   sync.Mutex
+	modules map[string]*LoxModule
+	loading map[string]*moduleLoad
+}
+
+// moduleLoad is a module being executed, tasks importing it meanwhile wait
+// until done is closed
+type moduleLoad struct {
+	done   chan struct{}
+	module *LoxModule
+	err    error
+}
+
+func newModuleRegistry() *moduleRegistry {
+	return &moduleRegistry{
+		modules: make(map[string]*LoxModule),
+		loading: make(map[string]*moduleLoad),
+	}
+}
+
+func (intr *Interpreter) importModule(token Token) (*LoxModule, error) {
+	// reading any file of the host is io, other loaders are chosen by the host
+	if _, ok := intr.loader.(OSModuleLoader); ok && !intr.isAllowed(CAPABILITY_IO) {
+		return nil, RuntimeError{
+			Token:   token,
+			Message: fmt.Sprintf("Permission denied: importing files requires the '%v' capability", CAPABILITY_IO),
+		}
+	}
+
+	path, err := intr.loader.Resolve(intr.path, token.Literal.(string), intr.searchPath)
+	if err != nil {
+		return nil, RuntimeError{
+			Token:   token,
+			Message: err.Error(),
+		}
+	}
+
+	for _, importing := range intr.importing {
+		if importing == path {
+			return nil, RuntimeError{
+				Token:   token,
+				Message: fmt.Sprintf("Import cycle detected while importing '%v'", path),
+			}
+		}
+	}
+
+	registry := intr.modules
+	registry.mutex.Lock()
+	if module, ok := registry.modules[path]; ok {
+		registry.mutex.Unlock()
+		return module, nil
+	} else if load, ok := registry.loading[path]; ok {
+		// another task is executing the module
+		registry.mutex.Unlock()
+		<-load.done
+		return load.module, load.err
+	}
+	load := &moduleLoad{done: make(chan struct{})}
+	registry.loading[path] = load
+	registry.mutex.Unlock()
+
+	load.module, load.err = intr.loadModule(token, path)
+
+	registry.mutex.Lock()
+	delete(registry.loading, path)
+	if load.err == nil {
+		registry.modules[path] = load.module
+	}
+	registry.mutex.Unlock()
+	close(load.done)
+
+	return load.module, load.err
+}
+
+func (intr *Interpreter) loadModule(token Token, path string) (*LoxModule, error) {
+	content, err := intr.loader.Load(path)
+	if err != nil {
+		return nil, RuntimeError{
+			Token:   token,
+			Message: fmt.Sprintf("Could not read module '%v': %v", path, err),
+		}
+	}
+
+	program, err := Compile(content)
+	if err != nil {
+		return nil, RuntimeError{
+			Token:   token,
+			Message: fmt.Sprintf("Could not compile module '%v':\n%v", path, err.Error()),
+		}
+	}
+
+	// modules share natives and configuration but get their own globals
+	child := intr.fork()
+	child.globals = NewEnvironment(intr.builtins)
+	child.environment = child.globals
+	child.path = path
+	child.importing = append(append([]string{}, intr.importing...), path)
+
+	for _, stmt := range program.Statements {
+		if err := child.execute(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LoxModule{
+		path:    path,
+		globals: child.globals,
+	}, nil
+}
+
+func (module *LoxModule) Get(name Token) (interface{}, error) {
+	module.globals.mutex.RLock()
+	defer module.globals.mutex.RUnlock()
+
+	if value, ok := module.globals.Values[name.Lexeme]; ok {
+		return value, nil
+	}
+
+	return nil, RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Module '%v' has no member '%v'", module.path, name.Lexeme),
+	}
+}
+
+func (module *LoxModule) Set(name Token, value interface{}) error {
+	return RuntimeError{
+		Token:   name,
+		Message: "Can't assign to a module member",
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleLoader finds and reads the source of imported modules, hosts can
+// implement it to serve modules from memory, a database, a zip file...
+type ModuleLoader interface {
+	// Resolve returns the canonical path of the module imported with the given
+	// name from the importer file, it is used to cache modules
+	Resolve(importer string, name string, searchPath []string) (string, error)
+	Load(path string) (string, error)
+}
+
+// OSModuleLoader reads modules from the operating system filesystem
+type OSModuleLoader struct{}
+
+// FSModuleLoader reads modules from a virtual filesystem such as an embed.FS
+type FSModuleLoader struct {
+	FS fs.FS
+}
+
+func NewFSModuleLoader(fsys fs.FS) FSModuleLoader {
+	return FSModuleLoader{FS: fsys}
+}
+
+func (loader OSModuleLoader) Resolve(importer string, name string, searchPath []string) (string, error) {
+	var candidates []string
+	if filepath.IsAbs(name) {
+		candidates = append(candidates, name)
+	} else {
+		candidates = append(candidates, filepath.Join(filepath.Dir(importer), name))
+		for _, directory := range searchPath {
+			candidates = append(candidates, filepath.Join(directory, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+
+	return "", fmt.Errorf("Module '%v' not found", name)
+}
+
+func (loader OSModuleLoader) Load(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	return string(content), err
+}
+
+func (loader FSModuleLoader) Resolve(importer string, name string, searchPath []string) (string, error) {
+	var candidates []string
+	if path.IsAbs(name) {
+		candidates = append(candidates, name)
+	} else {
+		candidates = append(candidates, path.Join(path.Dir(importer), name))
+		for _, directory := range searchPath {
+			candidates = append(candidates, path.Join(directory, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		// fs.FS paths are always relative to the root of the filesystem
+		candidate = strings.TrimPrefix(path.Clean(candidate), "/")
+		if !fs.ValidPath(candidate) {
+			continue
+		}
+
+		if info, err := fs.Stat(loader.FS, candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("Module '%v' not found", name)
+}
+
+func (loader FSModuleLoader) Load(path string) (string, error) {
+	content, err := fs.ReadFile(loader.FS, path)
+	return string(content), err
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestImportFromFilesystemRequiresIO(t *testing.T) {
+	_, stdout, stderr := runScript(t, `import "/etc/hostname" as h; print "after";`, WithCapabilities(CAPABILITY_CORE))
+
+	if !strings.Contains(stderr, "Permission denied: importing files requires the 'io' capability") {
+		t.Errorf("expected the import to be denied but got %q", stderr)
+	}
+
+	if strings.Contains(stderr, "Error at") || stdout != "after\n" {
+		t.Errorf("expected the file not to be compiled but got %q %q", stdout, stderr)
+	}
+}
+
+func TestImportThroughLoaderWithoutIO(t *testing.T) {
+	loader := NewFSModuleLoader(fstest.MapFS{
+		"lib/geometry.lox": {Data: []byte("fun area(r) { return PI * r * r; }")},
+	})
+
+	_, stdout, stderr := runScript(t, `
+		from "lib/geometry.lox" import area;
+		print area(1);
+	`, WithCapabilities(CAPABILITY_CORE), WithModuleLoader(loader))
+
+	if stdout != "3.141592653589793\n" || stderr != "" {
+		t.Errorf("unexpected output %q %q", stdout, stderr)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+var (
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+	interpreterType = reflect.TypeOf((*Interpreter)(nil))
+)
+
+// NativeLoxCallable exposes a Go function to Lox, arguments and return values
+// are converted between Lox and Go values using reflection
+type NativeLoxCallable struct {
+	name     string
+	function reflect.Value
+}
+
+func NewNativeLoxCallable(name string, function interface{}) NativeLoxCallable {
+	value := reflect.ValueOf(function)
+	if value.Kind() != reflect.Func {
+		panic(fmt.Sprintf("native '%v' must be a function but got %T", name, function))
+	} else if !hasNativeResults(value.Type()) {
+		panic(fmt.Sprintf("native '%v' must return at most one value and an error but got %T", name, function))
+	}
+
+	return NativeLoxCallable{
+		name:     name,
+		function: value,
+	}
+}
+
+// DefineNative makes a Go function available to scripts and their modules,
+// it may return a value, an error or both
+func (intr *Interpreter) DefineNative(name string, function interface{}) {
+	intr.builtins.Define(name, NewNativeLoxCallable(name, function))
+}
+
+func (lc NativeLoxCallable) Arity() int {
+	if lc.function.Type().IsVariadic() {
+		return VARIADIC_ARITY
+	}
+
+	return len(lc.parameters())
+}
+
+func (lc NativeLoxCallable) Call(intr *Interpreter, arguments []interface{}) (interface{}, error) {
+	fnType := lc.function.Type()
+	parameters := lc.parameters()
+
+	if fnType.IsVariadic() && len(arguments) < len(parameters)-1 {
+		return nil, RuntimeError{
+			Message: fmt.Sprintf("Expected at least %v arguments but got %v instead", len(parameters)-1, len(arguments)),
+		}
+	}
+
+	var in []reflect.Value
+	if fnType.NumIn() > len(parameters) {
+		in = append(in, reflect.ValueOf(intr))
+	}
+
+	for i, arg := range arguments {
+		var paramType reflect.Type
+		if fnType.IsVariadic() && i >= len(parameters)-1 {
+			paramType = parameters[len(parameters)-1].Elem()
+		} else {
+			paramType = parameters[i]
+		}
+
+		value, err := toGo(arg, paramType)
+		if err != nil {
+			return nil, RuntimeError{
+				Message: fmt.Sprintf("Argument %v of '%v' %v", i+1, lc.name, err.Error()),
+			}
+		}
+
+		in = append(in, value)
+	}
+
+	return lc.results(intr, lc.function.Call(in))
+}
+
+// hasNativeResults reports whether the function returns nothing, a value, an
+// error or a value and an error, the only results natives can have
+func hasNativeResults(fnType reflect.Type) bool {
+	switch fnType.NumOut() {
+	case 0, 1:
+		return true
+	case 2:
+		return fnType.Out(1) == errorType
+	default:
+		return false
+	}
+}
+
+// parameters are the arguments visible to Lox, natives can optionally take
+// the interpreter running them as their first parameter
+func (lc NativeLoxCallable) parameters() []reflect.Type {
+	fnType := lc.function.Type()
+
+	var parameters []reflect.Type
+	for i := 0; i < fnType.NumIn(); i += 1 {
+		if i == 0 && fnType.In(i) == interpreterType {
+			continue
+		}
+
+		parameters = append(parameters, fnType.In(i))
+	}
+
+	return parameters
+}
+
+func (lc NativeLoxCallable) results(intr *Interpreter, results []reflect.Value) (interface{}, error) {
+	if len(results) > 0 && results[len(results)-1].Type() == errorType {
+		if err, ok := results[len(results)-1].Interface().(error); ok && err != nil {
+			switch err.(type) {
+			case RuntimeError, Exit:
+				return nil, err
+			}
+
+			return nil, RuntimeError{Message: err.Error()}
+		}
+
+		results = results[:len(results)-1]
+	}
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	value, err := fromGo(results[0].Interface())
+	if err != nil {
+		return nil, RuntimeError{Message: fmt.Sprintf("'%v' returned a value Lox can't use: %v", lc.name, err.Error())}
+	}
+
+	if err := intr.chargeNew(Token{}, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// toGo converts a Lox value into a Go value of the given type, nil is only
+// accepted for interface{} and where Go has an empty value (slices and maps)
+// so natives never receive nil pointers or functions
+func toGo(value interface{}, goType reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch goType.Kind() {
+		case reflect.Interface:
+			if goType.NumMethod() == 0 {
+				return reflect.Zero(goType), nil
+			}
+		case reflect.Slice, reflect.Map:
+			return reflect.Zero(goType), nil
+		}
+	}
+
+	switch goType.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f, ok := value.(float64); ok {
+			return reflect.ValueOf(f).Convert(goType), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, ok := value.(float64); ok && f == math.Trunc(f) {
+			max := int64(1)<<(goType.Bits()-1) - 1
+			if f < float64(-max-1) || f >= -float64(-max-1) {
+				return reflect.Value{}, fmt.Errorf("must be an integer between %v and %v but got %v", -max-1, max, formatNumber(f))
+			}
+
+			return reflect.ValueOf(int64(f)).Convert(goType), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, ok := value.(float64); ok && f == math.Trunc(f) && f >= 0 {
+			max := uint64(1)<<goType.Bits() - 1
+			if f >= float64(max)+1 {
+				return reflect.Value{}, fmt.Errorf("must be an integer between 0 and %v but got %v", max, formatNumber(f))
+			}
+
+			return reflect.ValueOf(uint64(f)).Convert(goType), nil
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s).Convert(goType), nil
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			return reflect.ValueOf(b).Convert(goType), nil
+		}
+	case reflect.Slice:
+		if list, ok := value.(*LoxList); ok {
+			elements := list.Elements()
+			slice := reflect.MakeSlice(goType, len(elements), len(elements))
+			for i, element := range elements {
+				converted, err := toGo(element, goType.Elem())
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("element %v %v", i, err.Error())
+				}
+
+				slice.Index(i).Set(converted)
+			}
+
+			return slice, nil
+		}
+	default:
+		if value != nil && reflect.TypeOf(value).AssignableTo(goType) {
+			return reflect.ValueOf(value), nil
+		}
+
+		if obj, ok := value.(*GoObject); ok {
+			if obj.value.Type().AssignableTo(goType) {
+				return obj.value, nil
+			} else if obj.structValue().Type().AssignableTo(goType) {
+				return obj.structValue(), nil
+			}
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("must be %v but got %v", goTypeName(goType), typeName(value))
+}
+
+// fromGo converts a Go value into the Lox value that represents it, values
+// with no Lox equivalent (channels, complex numbers...) are rejected
+func fromGo(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil:
+		return nil, nil
+	case LoxCallable, LoxObject, *LoxPromise:
+		return value, nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Struct:
+		return NewGoObject(value), nil
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Elem().Kind() == reflect.Struct {
+			return NewGoObject(value), nil
+		}
+	case reflect.Func:
+		if hasNativeResults(v.Type()) {
+			return NewNativeLoxCallable("native", value), nil
+		}
+	case reflect.Slice, reflect.Array:
+		elements := make([]interface{}, v.Len())
+		for i := range elements {
+			element, err := fromGo(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			elements[i] = element
+		}
+
+		return NewLoxList(elements...), nil
+	case reflect.Map:
+		return mapFromGo(v)
+	}
+
+	return nil, fmt.Errorf("Go values of type %T have no Lox equivalent", value)
+}
+
+// mapFromGo converts a Go map, keys are sorted since Go maps have no order
+func mapFromGo(v reflect.Value) (*LoxMap, error) {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	m := NewLoxMap()
+	for _, goKey := range keys {
+		key, err := fromGo(goKey.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkMapKey(key); err != nil {
+			return nil, err
+		}
+
+		value, err := fromGo(v.MapIndex(goKey).Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		m.put(key, value)
+	}
+
+	return m, nil
+}
+
+// toHost converts a Lox value into the plain Go value handed back to embedders
+func toHost(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *GoObject:
+		return v.value.Interface()
+	case *LoxList:
+		elements := v.Elements()
+		for i, element := range elements {
+			elements[i] = toHost(element)
+		}
+
+		return elements
+	case *LoxMap:
+		entries := make(map[interface{}]interface{})
+		for _, key := range v.Keys() {
+			value, _ := v.Lookup(key)
+			entries[key] = toHost(value)
+		}
+
+		return entries
+	}
+
+	return value
+}
+
+// typeName is the name of the type of a Lox value as shown in error messages
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case LoxCallable:
+		return "a function"
+	case *LoxList:
+		return "a list"
+	case *LoxMap:
+		return "a map"
+	case *LoxGenerator:
+		return "a generator"
+	case *LoxPromise:
+		return "a promise"
+	case *LoxModule:
+		return "a module"
+	case *GoObject:
+		return fmt.Sprintf("a %v object", v.structValue().Type().Name())
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func goTypeName(goType reflect.Type) string {
+	switch goType.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice:
+		return "a list"
+	}
+
+	if goType == reflect.TypeOf((*LoxCallable)(nil)).Elem() {
+		return "a function"
+	} else if goType == reflect.TypeOf((*LoxList)(nil)) {
+		return "a list"
+	} else if goType == reflect.TypeOf((*LoxMap)(nil)) {
+		return "a map"
+	} else if goType == reflect.TypeOf((*LoxChannel)(nil)) {
+		return "a channel"
+	} else if goType.Kind() == reflect.Ptr && goType.Elem().Kind() == reflect.Struct {
+		return fmt.Sprintf("a %v object", goType.Elem().Name())
+	}
+
+	return goType.String()
+}
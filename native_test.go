@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNativeScalarResultsAreNotCharged(t *testing.T) {
+	intr, stdout, stderr := runScript(t, `
+		var x = 0;
+		for (var i = 0; i < 100000; i = i + 1) {
+			x = abs(i);
+		}
+		print x;
+	`, WithMemoryLimit(1000000))
+
+	if stdout != "99999\n" || stderr != "" {
+		t.Fatalf("expected the loop to fit in the limit but got %q %q", stdout, stderr)
+	}
+
+	if used := atomic.LoadInt64(intr.memoryUsed); used > 100 {
+		t.Errorf("expected only the globals to be charged but %v bytes are used", used)
+	}
+}
+
+func TestNativeArgumentsAreChecked(t *testing.T) {
+	for source, message := range map[string]string{
+		`choice(nil);`:                 "Argument 1 of 'choice' must be a list but got nil",
+		`recv(nil);`:                   "Argument 1 of 'recv' must be a channel but got nil",
+		`randomInt(0, pow(10, 30));`:   "Argument 2 of 'randomInt' must be an integer between",
+		`repeat("a", 0 - pow(2, 64));`: "Argument 2 of 'repeat' must be an integer between",
+		`sqrt("4");`:                   "Argument 1 of 'sqrt' must be a number but got a string",
+	} {
+		_, _, stderr := runScript(t, source)
+		if !strings.Contains(stderr, message) {
+			t.Errorf("expected %q to fail with %q but got %q", source, message, stderr)
+		}
+	}
+}
+
+func TestNativeResultsAreConverted(t *testing.T) {
+	program, err := Compile(`print pairs(); channel();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	intr := NewInterpreter(WithStdout(&stdout), WithStderr(&stderr))
+	intr.DefineNative("pairs", func() map[string]int { return map[string]int{"b": 2, "a": 1} })
+	intr.DefineNative("channel", func() chan int { return make(chan int) })
+	intr.Run(program)
+
+	if stdout.String() != "{\"a\": 1, \"b\": 2}\n" {
+		t.Errorf("expected the Go map to become a map but got %q", stdout.String())
+	}
+
+	if !strings.Contains(stderr.String(), "'channel' returned a value Lox can't use") {
+		t.Errorf("expected the Go channel to be rejected but got %q", stderr.String())
+	}
+}
+
+type multipleResults struct{}
+
+func (multipleResults) Pair() (int, string) { return 1, "one" }
+
+func (multipleResults) One() (int, error) { return 1, nil }
+
+func TestNativesCantReturnSeveralValues(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a function with several results to be rejected")
+		}
+	}()
+
+	NewNativeLoxCallable("pair", func() (int, string) { return 1, "one" })
+}
+
+func TestMethodsReturningSeveralValuesAreHidden(t *testing.T) {
+	program, err := Compile(`print object.one(); object.pair();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	intr := NewInterpreter(WithStdout(&stdout), WithStderr(&stderr))
+	intr.DefineObject("object", multipleResults{})
+	intr.Run(program)
+
+	if stdout.String() != "1\n" || !strings.Contains(stderr.String(), "Undefined property 'pair'") {
+		t.Errorf("unexpected output %q %q", stdout.String(), stderr.String())
+	}
+}
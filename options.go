@@ -0,0 +1,103 @@
+package main
+
+import "io"
+
+type InterpreterOption func(intr *Interpreter)
+
+// WithMemoryLimit sets an allocation budget in bytes for the lifetime of the
+// interpreter, a limit <= 0 means no limit. Values are charged when created
+// and never given back, only scopes are refunded once left, so it bounds what
+// a script allocates in total rather than what it holds at once
+func WithMemoryLimit(bytes int) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.memoryLimit = bytes
+	}
+}
+
+// WithPath sets the file of the script, imports are resolved relative to it
+func WithPath(path string) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.path = path
+	}
+}
+
+// WithSearchPath adds directories where imports not found relative to the
+// importing file are looked up
+func WithSearchPath(directories ...string) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.searchPath = append(intr.searchPath, directories...)
+	}
+}
+
+// WithModuleLoader replaces the loader used to find and read imported modules
+func WithModuleLoader(loader ModuleLoader) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.loader = loader
+	}
+}
+
+// WithCapabilities switches the interpreter to deny-by-default mode where only
+// the natives of the given capabilities can be called, without io modules can
+// only be imported through a loader set with WithModuleLoader
+func WithCapabilities(names ...string) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.capabilities = make(map[string]bool)
+		for _, name := range names {
+			intr.capabilities[name] = true
+		}
+	}
+}
+
+// WithoutNatives removes individual natives even if their capability is
+// granted, calling them fails with a permission error
+func WithoutNatives(names ...string) InterpreterOption {
+	return func(intr *Interpreter) {
+		if intr.disabled == nil {
+			intr.disabled = make(map[string]bool)
+		}
+
+		for _, name := range names {
+			intr.disabled[name] = true
+		}
+	}
+}
+
+// WithArgs sets the arguments returned by args()
+func WithArgs(args ...string) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.args = args
+	}
+}
+
+// WithRandomSeed makes the random natives produce the same sequence on every
+// run, otherwise the generator is seeded from the current time
+func WithRandomSeed(seed int64) InterpreterOption {
+	return func(intr *Interpreter) {
+		intr.random = newRandomSource(seed)
+	}
+}
+
+// WithStdout sets where print and write send their output
+func WithStdout(writer io.Writer) InterpreterOption {
+	// wrapped once so interpreters sharing the option also share the lock
+	stdout := newSyncWriter(writer)
+	return func(intr *Interpreter) {
+		intr.stdout = stdout
+	}
+}
+
+// WithStderr sets where runtime errors are reported
+func WithStderr(writer io.Writer) InterpreterOption {
+	stderr := newSyncWriter(writer)
+	return func(intr *Interpreter) {
+		intr.stderr = stderr
+	}
+}
+
+// WithStdin sets where input and readLine read lines from
+func WithStdin(reader io.Reader) InterpreterOption {
+	stdin := newLineReader(reader)
+	return func(intr *Interpreter) {
+		intr.stdin = stdin
+	}
+}
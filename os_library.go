@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func init() {
+	registerNatives(CAPABILITY_OS, map[string]interface{}{
+		"env":    env,
+		"setEnv": setEnv,
+		"args":   args,
+		"exit":   exit,
+		"cwd":    cwd,
+		"exec":   execCommand,
+	})
+}
+
+// env returns nil when the variable is not set
+func env(name string) interface{} {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+
+	return nil
+}
+
+// setEnv removes the variable when the value is nil
+func setEnv(name string, value interface{}) error {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		err = os.Unsetenv(name)
+	case string:
+		err = os.Setenv(name, v)
+	default:
+		return fmt.Errorf("Argument 2 of 'setEnv' must be a string or nil but got %v", typeName(value))
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't set environment variable '%v': %v", name, err.Error())
+	}
+
+	return nil
+}
+
+// args returns the arguments given to the script
+func args(intr *Interpreter) []string {
+	return append([]string{}, intr.args...)
+}
+
+// exit stops the script, hosts get an Exit error with the status code
+func exit(code ...int) error {
+	if len(code) > 1 {
+		return fmt.Errorf("Expected at most 1 argument but got %v", len(code))
+	} else if len(code) == 1 {
+		return Exit{Code: code[0]}
+	}
+
+	return Exit{Code: 0}
+}
+
+func cwd() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("Can't get the current directory: %v", err.Error())
+	}
+
+	return dir, nil
+}
+
+// execCommand runs the program without a shell and waits for it, the result
+// is a map with its stdout, stderr and exit code
+func execCommand(intr *Interpreter, name string, arguments ...[]string) (*LoxMap, error) {
+	if len(arguments) > 1 {
+		return nil, fmt.Errorf("Expected at most 2 arguments but got %v", len(arguments)+1)
+	}
+
+	var commandArgs []string
+	if len(arguments) == 1 {
+		commandArgs = arguments[0]
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(name, commandArgs...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	code := 0
+	if err := command.Run(); err != nil {
+		var exitErr *exec.ExitError
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return nil, fmt.Errorf("Can't run '%v': %v", name, execErr.Err.Error())
+		} else if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("Can't run '%v': %v", name, err.Error())
+		}
+
+		code = exitErr.ExitCode()
+	}
+
+	if err := intr.allocate(Token{}, stdout.Len()+stderr.Len()); err != nil {
+		return nil, err
+	}
+
+	result := NewLoxMap()
+	result.put("stdout", stdout.String())
+	result.put("stderr", stderr.String())
+	result.put("code", float64(code))
+	return result, nil
+}
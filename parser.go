@@ -1,14 +1,12 @@
 package main
 
-import (
-	"errors"
-	"fmt"
-)
+import "fmt"
 
 const ARGUMENTS_LIMIT = 255
 
 type Parser struct {
 	Tokens  []Token
+	Errors  []SyntaxError
 	current int
 }
 
@@ -22,12 +20,15 @@ func NewParser(tokens []Token) *Parser {
 func (parser *Parser) Parse() ([]Stmt, error) {
 	var statements []Stmt
 	for !parser.isAtEnd() {
-		stmt, err := parser.declaration()
-		if err != nil {
-			return nil, err
+		// declaration synchronizes after an error so parsing goes on to
+		// report the following ones
+		if stmt, err := parser.declaration(); err == nil {
+			statements = append(statements, stmt)
 		}
+	}
 
-		statements = append(statements, stmt)
+	if len(parser.Errors) > 0 {
+		return nil, CompileError{Errors: parser.Errors}
 	}
 
 	return statements, nil
@@ -45,6 +46,14 @@ func (parser *Parser) declaration() (Stmt, error) {
 		} else {
 			return stmt, nil
 		}
+	} else if parser.match(ASYNC) {
+		stmt, err = parser.asyncFunDeclarationStatement()
+		if err != nil {
+			parser.synchronize()
+			return nil, err
+		} else {
+			return stmt, nil
+		}
 	} else if parser.match(VAR) {
 		stmt, err = parser.varDeclarationStatement()
 		if err != nil {
@@ -65,6 +74,8 @@ func (parser *Parser) declaration() (Stmt, error) {
 }
 
 func (parser *Parser) funDeclarationStatement(key string) (Stmt, error) {
+	isGenerator := parser.match(STAR)
+
 	name, err := parser.consume(IDENTIFIER, fmt.Sprintf("Expected %v name", key))
 	if err != nil {
 		return nil, err
@@ -91,7 +102,7 @@ func (parser *Parser) funDeclarationStatement(key string) (Stmt, error) {
 	}
 
 	if len(parameters) >= ARGUMENTS_LIMIT {
-		LoxTokenError(parser.peek(), fmt.Sprintf("Can't have more than %v arguments", ARGUMENTS_LIMIT))
+		parser.error(parser.peek(), fmt.Sprintf("Can't have more than %v arguments", ARGUMENTS_LIMIT))
 	}
 
 	_, err = parser.consume(RIGHT_PAREN, "Expected ')' after arguments list")
@@ -110,12 +121,28 @@ func (parser *Parser) funDeclarationStatement(key string) (Stmt, error) {
 	}
 
 	return StmtFunction{
-		Name:       name,
-		Parameters: parameters,
-		Body:       body,
+		Name:        name,
+		Parameters:  parameters,
+		Body:        body,
+		IsGenerator: isGenerator,
 	}, nil
 }
 
+func (parser *Parser) asyncFunDeclarationStatement() (Stmt, error) {
+	if _, err := parser.consume(FUN, "Expected 'fun' after 'async'"); err != nil {
+		return nil, err
+	}
+
+	stmt, err := parser.funDeclarationStatement("function")
+	if err != nil {
+		return nil, err
+	}
+
+	function := stmt.(StmtFunction)
+	function.IsAsync = true
+	return function, nil
+}
+
 func (parser *Parser) varDeclarationStatement() (Stmt, error) {
 	name, err := parser.consume(IDENTIFIER, "Expected variable name")
 	if err != nil {
@@ -162,6 +189,12 @@ func (parser *Parser) block() ([]Stmt, error) {
 func (parser *Parser) statement() (Stmt, error) {
 	if parser.match(RETURN) {
 		return parser.returnStatement()
+	} else if parser.match(YIELD) {
+		return parser.yieldStatement()
+	} else if parser.match(IMPORT) {
+		return parser.importStatement()
+	} else if parser.match(FROM) {
+		return parser.fromImportStatement()
 	} else if parser.match(FOR) {
 		return parser.forStatement()
 	} else if parser.match(WHILE) {
@@ -171,11 +204,12 @@ func (parser *Parser) statement() (Stmt, error) {
 	} else if parser.match(PRINT) {
 		return parser.printStatement()
 	} else if parser.match(LEFT_BRACE) {
+		brace := parser.previous()
 		statements, err := parser.block()
 		if err != nil {
 			return nil, err
 		} else {
-			return StmtBlock{Statements: statements}, nil
+			return StmtBlock{Brace: brace, Statements: statements}, nil
 		}
 	} else {
 		return parser.expressionStatement()
@@ -204,12 +238,105 @@ func (parser *Parser) returnStatement() (Stmt, error) {
 	}, nil
 }
 
+func (parser *Parser) yieldStatement() (Stmt, error) {
+	keyword := parser.previous()
+
+	var expr Expr
+	var err error
+	if !parser.check(SEMICOLON) {
+		expr, err = parser.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := parser.consume(SEMICOLON, "Expected ';' after yield value"); err != nil {
+		return nil, err
+	}
+
+	return StmtYield{
+		Keyword:    keyword,
+		Expression: expr,
+	}, nil
+}
+
+func (parser *Parser) importStatement() (Stmt, error) {
+	keyword := parser.previous()
+
+	path, err := parser.consume(STRING, "Expected module path after 'import'")
+	if err != nil {
+		return nil, err
+	}
+
+	var alias *Token
+	if parser.match(AS) {
+		name, err := parser.consume(IDENTIFIER, "Expected module name after 'as'")
+		if err != nil {
+			return nil, err
+		}
+
+		alias = &name
+	}
+
+	if _, err := parser.consume(SEMICOLON, "Expected ';' after import"); err != nil {
+		return nil, err
+	}
+
+	return StmtImport{
+		Keyword: keyword,
+		Path:    path,
+		Alias:   alias,
+	}, nil
+}
+
+func (parser *Parser) fromImportStatement() (Stmt, error) {
+	keyword := parser.previous()
+
+	path, err := parser.consume(STRING, "Expected module path after 'from'")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := parser.consume(IMPORT, "Expected 'import' after module path"); err != nil {
+		return nil, err
+	}
+
+	var names []Token
+	for {
+		name, err := parser.consume(IDENTIFIER, "Expected name to import")
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+
+		if !parser.match(COMMA) {
+			break
+		}
+	}
+
+	if _, err := parser.consume(SEMICOLON, "Expected ';' after import"); err != nil {
+		return nil, err
+	}
+
+	return StmtImport{
+		Keyword: keyword,
+		Path:    path,
+		Names:   names,
+	}, nil
+}
+
 func (parser *Parser) forStatement() (Stmt, error) {
 	var err error
+	keyword := parser.previous()
 	if _, err := parser.consume(LEFT_PAREN, "Expected '(' after while"); err != nil {
 		return nil, err
 	}
 
+	if parser.check(VAR) && parser.checkAhead(2, IN) {
+		return parser.forInStatement()
+	}
+
 	var initializer Stmt
 	if parser.match(VAR) {
 		initializer, err = parser.varDeclarationStatement()
@@ -256,6 +383,7 @@ func (parser *Parser) forStatement() (Stmt, error) {
 
 	if increment != nil {
 		body = StmtBlock{
+			Brace: keyword,
 			Statements: []Stmt{
 				body,
 				StmtExpression{Expression: increment},
@@ -273,6 +401,7 @@ func (parser *Parser) forStatement() (Stmt, error) {
 
 	if initializer != nil {
 		body = StmtBlock{
+			Brace: keyword,
 			Statements: []Stmt{
 				initializer,
 				body,
@@ -283,6 +412,39 @@ func (parser *Parser) forStatement() (Stmt, error) {
 	return body, nil
 }
 
+func (parser *Parser) forInStatement() (Stmt, error) {
+	parser.advance() // var
+
+	name, err := parser.consume(IDENTIFIER, "Expected variable name")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := parser.consume(IN, "Expected 'in' after variable name"); err != nil {
+		return nil, err
+	}
+
+	iterable, err := parser.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := parser.consume(RIGHT_PAREN, "Expected ')' after iterable"); err != nil {
+		return nil, err
+	}
+
+	body, err := parser.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return StmtForIn{
+		Name:     name,
+		Iterable: iterable,
+		Body:     body,
+	}, nil
+}
+
 func (parser *Parser) whileStatement() (Stmt, error) {
 	if _, err := parser.consume(LEFT_PAREN, "Expected '(' after while"); err != nil {
 		return nil, err
@@ -343,16 +505,26 @@ func (parser *Parser) ifStatement() (Stmt, error) {
 }
 
 func (parser *Parser) printStatement() (Stmt, error) {
-	expr, err := parser.expression()
-	if err != nil {
-		return nil, err
+	var exprs []Expr
+	for {
+		// values are separated by commas so we skip the comma operator
+		expr, err := parser.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		exprs = append(exprs, expr)
+
+		if !parser.match(COMMA) {
+			break
+		}
 	}
 
 	if _, err := parser.consume(SEMICOLON, "Expected ';' after value"); err != nil {
 		return nil, err
 	}
 
-	return StmtPrint{Expression: expr}, nil
+	return StmtPrint{Expressions: exprs}, nil
 }
 
 func (parser *Parser) expressionStatement() (Stmt, error) {
@@ -422,6 +594,12 @@ func (parser *Parser) check(tokenType TokenType) bool {
 	}
 }
 
+// checkAhead looks at the token the given number of positions after the current one
+func (parser *Parser) checkAhead(distance int, tokenType TokenType) bool {
+	index := parser.current + distance
+	return index < len(parser.Tokens) && parser.Tokens[index].TokenType == tokenType
+}
+
 func (parser *Parser) isAtEnd() bool {
 	return parser.current >= len(parser.Tokens) || parser.Tokens[parser.current].TokenType == EOF
 }
@@ -461,9 +639,14 @@ func (parser *Parser) assignment() (Expr, error) {
 				Name:  varExpr.Name,
 				Value: value,
 			}, nil
+		} else if getExpr, ok := expr.(ExprGet); ok {
+			return ExprSet{
+				Object: getExpr.Object,
+				Name:   getExpr.Name,
+				Value:  value,
+			}, nil
 		} else {
-			LoxTokenError(equals, "Invalid assignment target")
-			return nil, errors.New("Invalid assignment target")
+			return nil, parser.error(equals, "Invalid assignment target")
 		}
 	} else {
 		return expr, nil
@@ -502,6 +685,13 @@ func (parser *Parser) unary() (Expr, error) {
 			Operator: operator,
 			Right:    right,
 		}, err
+	} else if parser.match(AWAIT) {
+		keyword := parser.previous()
+		right, err := parser.unary()
+		return ExprAwait{
+			Keyword:    keyword,
+			Expression: right,
+		}, err
 	} else {
 		return parser.call()
 	}
@@ -513,10 +703,24 @@ func (parser *Parser) call() (Expr, error) {
 		return nil, err
 	}
 
-	for parser.match(LEFT_PAREN) {
-		expr, err = parser.finishCall(expr)
-		if err != nil {
-			return nil, err
+	for {
+		if parser.match(LEFT_PAREN) {
+			expr, err = parser.finishCall(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else if parser.match(DOT) {
+			name, err := parser.consume(IDENTIFIER, "Expected property name after '.'")
+			if err != nil {
+				return nil, err
+			}
+
+			expr = ExprGet{
+				Object: expr,
+				Name:   name,
+			}
+		} else {
+			break
 		}
 	}
 
@@ -527,7 +731,8 @@ func (parser *Parser) finishCall(callee Expr) (Expr, error) {
 	var arguments []Expr
 	if !parser.check(RIGHT_PAREN) {
 		for {
-			arg, err := parser.expression()
+			// arguments are separated by commas so we skip the comma operator
+			arg, err := parser.assignment()
 			if err != nil {
 				return nil, err
 			}
@@ -541,7 +746,7 @@ func (parser *Parser) finishCall(callee Expr) (Expr, error) {
 	}
 
 	if len(arguments) >= ARGUMENTS_LIMIT {
-		LoxTokenError(parser.peek(), fmt.Sprintf("Can't have more than %v arguments", ARGUMENTS_LIMIT))
+		parser.error(parser.peek(), fmt.Sprintf("Can't have more than %v arguments", ARGUMENTS_LIMIT))
 	}
 
 	paren, err := parser.consume(RIGHT_PAREN, "Expected ')' after arguments list")
@@ -566,6 +771,8 @@ func (parser *Parser) primary() (Expr, error) {
 		return ExprLiteral{Value: nil}, nil
 	case parser.match(NUMBER, STRING):
 		return ExprLiteral{Value: parser.previous().Literal}, nil
+	case parser.match(INTERPOLATION):
+		return parser.interpolation()
 	case parser.match(IDENTIFIER):
 		return ExprVariable{Name: parser.previous()}, nil
 	case parser.match(LEFT_PAREN):
@@ -580,20 +787,64 @@ func (parser *Parser) primary() (Expr, error) {
 
 		return ExprGrouping{Expression: expr}, err
 	default:
-		LoxTokenError(parser.peek(), "Expected expression")
-		return nil, errors.New("Expected expression")
+		return nil, parser.error(parser.peek(), "Expected expression")
 	}
 }
 
+func (parser *Parser) interpolation() (Expr, error) {
+	token := parser.previous()
+
+	var parts []Expr
+	for {
+		parts = append(parts, ExprLiteral{Value: parser.previous().Literal})
+
+		expr, err := parser.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, expr)
+
+		if !parser.match(INTERPOLATION) {
+			break
+		}
+	}
+
+	end, err := parser.consume(STRING, "Expected '}' after interpolated expression")
+	if err != nil {
+		return nil, err
+	}
+
+	parts = append(parts, ExprLiteral{Value: end.Literal})
+
+	return ExprInterpolation{
+		Token: token,
+		Parts: parts,
+	}, nil
+}
+
 func (parser *Parser) consume(tokenType TokenType, message string) (Token, error) {
 	if parser.check(tokenType) {
 		return parser.advance(), nil
 	} else {
-		LoxTokenError(parser.peek(), message)
-		return Token{}, errors.New("Unexpected token")
+		return Token{}, parser.error(parser.peek(), message)
 	}
 }
 
+// error records the error, parsing stops at the returned one unless the
+// error doesn't prevent building the statement
+func (parser *Parser) error(token Token, message string) error {
+	err := SyntaxError{Line: token.Line, Message: message}
+	if token.TokenType == EOF {
+		err.Where = "at end"
+	} else {
+		err.Where = fmt.Sprintf("at '%v'", token.Lexeme)
+	}
+
+	parser.Errors = append(parser.Errors, err)
+	return err
+}
+
 func (parser *Parser) synchronize() {
 	parser.advance()
 	for !parser.isAtEnd() {
@@ -602,7 +853,7 @@ func (parser *Parser) synchronize() {
 		}
 
 		switch parser.peek().TokenType {
-		case CLASS, FOR, FUN, IF, PRINT, RETURN, VAR, WHILE:
+		case ASYNC, CLASS, FOR, FROM, FUN, IF, IMPORT, PRINT, RETURN, VAR, WHILE, YIELD:
 			return
 		}
 
@@ -0,0 +1,33 @@
+package main
+
+// Pool runs programs concurrently, each run gets its own interpreter so
+// scripts never share state
+type Pool struct {
+	options []InterpreterOption
+	slots   chan struct{}
+}
+
+func NewPool(size int, options ...InterpreterOption) *Pool {
+	return &Pool{
+		options: options,
+		slots:   make(chan struct{}, size),
+	}
+}
+
+// Run executes the program on a fresh interpreter waiting for a free slot if
+// the pool is busy, setup functions run before the program (e.g. to define
+// natives) and the interpreter is returned so results can be inspected, its
+// unfinished generators are closed
+func (pool *Pool) Run(program *Program, setup ...func(intr *Interpreter)) (*Interpreter, error) {
+	pool.slots <- struct{}{}
+	defer func() { <-pool.slots }()
+
+	intr := NewInterpreter(pool.options...)
+	defer intr.Close()
+
+	for _, fn := range setup {
+		fn(intr)
+	}
+
+	return intr, intr.Run(program)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolRunsProgramsInParallel(t *testing.T) {
+	const runs = 4
+
+	program, err := Compile(`
+		var total = 0;
+		for (var i = 1; i <= 100; i = i + 1) {
+			total = total + i;
+		}
+		arrive();
+		print "${id()} ${total}";
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every run waits for the others inside the script, so the runs only
+	// finish if the pool executes them at the same time
+	var arrived sync.WaitGroup
+	arrived.Add(runs)
+	all := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(all)
+	}()
+
+	arrive := func() error {
+		arrived.Done()
+		select {
+		case <-all:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("Runs did not execute in parallel")
+		}
+	}
+
+	pool := NewPool(runs)
+	outputs := make([]bytes.Buffer, runs)
+	errs := make([]error, runs)
+
+	var done sync.WaitGroup
+	for i := 0; i < runs; i += 1 {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+
+			_, errs[i] = pool.Run(program, func(intr *Interpreter) {
+				WithStdout(&outputs[i])(intr)
+				intr.DefineNative("arrive", arrive)
+				intr.DefineNative("id", func() int { return i })
+			})
+		}(i)
+	}
+	done.Wait()
+
+	for i := 0; i < runs; i += 1 {
+		if errs[i] != nil {
+			t.Errorf("run %v failed: %v", i, errs[i])
+		} else if expected := fmt.Sprintf("%v 5050\n", i); outputs[i].String() != expected {
+			t.Errorf("run %v printed %q instead of %q", i, outputs[i].String(), expected)
+		}
+	}
+}
+
+func TestCompileReportsErrorsPerSource(t *testing.T) {
+	sources := []string{
+		"print 1;",
+		"var = 1;",
+		"print 1;\nprint 2 +;",
+		"print \"a\" $;",
+	}
+	expected := []string{
+		"",
+		"[line 1] Error at '=': Expected variable name",
+		"[line 2] Error at ';': Expected expression",
+		"[line 1] Error: Unexpected character '$' at column 11",
+	}
+
+	var done sync.WaitGroup
+	for n := 0; n < 50; n += 1 {
+		for i := range sources {
+			done.Add(1)
+			go func(i int) {
+				defer done.Done()
+
+				_, err := Compile(sources[i])
+				if expected[i] == "" {
+					if err != nil {
+						t.Errorf("compiling %q failed: %v", sources[i], err)
+					}
+
+					return
+				}
+
+				var compileErr CompileError
+				if !errors.As(err, &compileErr) || err.Error() != expected[i] {
+					t.Errorf("compiling %q returned %v instead of %v", sources[i], err, expected[i])
+				}
+			}(i)
+		}
+	}
+	done.Wait()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Program is a parsed script, it is never modified once compiled so it can be
+// run by any number of interpreters at the same time
+type Program struct {
+	Statements []Stmt
+}
+
+// SyntaxError is a mistake found while scanning or parsing, where tells the
+// token it was found at (if any)
+type SyntaxError struct {
+	Line    int
+	Where   string
+	Message string
+}
+
+// CompileError holds every syntax error of a source, compiling never writes
+// diagnostics itself so any number of sources can be compiled concurrently
+type CompileError struct {
+	Errors []SyntaxError
+}
+
+func (err SyntaxError) Error() string {
+	if err.Where == "" {
+		return fmt.Sprintf("[line %v] Error: %v", err.Line, err.Message)
+	}
+
+	return fmt.Sprintf("[line %v] Error %v: %v", err.Line, err.Where, err.Message)
+}
+
+func (err CompileError) Error() string {
+	messages := make([]string, len(err.Errors))
+	for i, syntaxErr := range err.Errors {
+		messages[i] = syntaxErr.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+func Compile(content string) (*Program, error) {
+	scanner := NewScanner(content)
+	tokens := scanner.ScanTokens()
+	if len(scanner.Errors) > 0 {
+		return nil, CompileError{Errors: scanner.Errors}
+	}
+
+	statements, err := NewParser(tokens).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Program{Statements: statements}, nil
+}
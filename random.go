@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// randomSource is the generator of an interpreter, it is shared with its
+// forks so it must be synchronized
+type randomSource struct {
+	mutex sync.Mutex
+	rng   *rand.Rand
+}
+
+func init() {
+	registerNatives(CAPABILITY_MATH, map[string]interface{}{
+		"random":    random,
+		"randomInt": randomInt,
+		"choice":    choice,
+		"shuffle":   shuffle,
+		"seed":      seed,
+	})
+}
+
+func newRandomSource(seed int64) *randomSource {
+	return &randomSource{rng: rand.New(rand.NewSource(seed))}
+}
+
+func newTimeSeededRandomSource() *randomSource {
+	return newRandomSource(time.Now().UnixNano())
+}
+
+// random returns a number in [0, 1)
+func random(intr *Interpreter) float64 {
+	intr.random.mutex.Lock()
+	defer intr.random.mutex.Unlock()
+
+	return intr.random.rng.Float64()
+}
+
+// randomInt returns an integer between low and high, both included
+func randomInt(intr *Interpreter, low int64, high int64) (int64, error) {
+	if high < low {
+		return 0, fmt.Errorf("Upper bound %v is smaller than lower bound %v", high, low)
+	}
+
+	// numbers are only exact up to 2^53 so larger ranges make no sense
+	if low < -1<<53 || high > 1<<53 {
+		return 0, errors.New("Bounds must be between -2^53 and 2^53")
+	}
+
+	intr.random.mutex.Lock()
+	defer intr.random.mutex.Unlock()
+
+	return low + intr.random.rng.Int63n(high-low+1), nil
+}
+
+func choice(intr *Interpreter, list *LoxList) (interface{}, error) {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if len(list.elements) == 0 {
+		return nil, errors.New("Can't choose from an empty list")
+	}
+
+	intr.random.mutex.Lock()
+	defer intr.random.mutex.Unlock()
+
+	return list.elements[intr.random.rng.Intn(len(list.elements))], nil
+}
+
+// shuffle reorders the list in place
+func shuffle(intr *Interpreter, list *LoxList) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	intr.random.mutex.Lock()
+	defer intr.random.mutex.Unlock()
+
+	intr.random.rng.Shuffle(len(list.elements), func(i, j int) {
+		list.elements[i], list.elements[j] = list.elements[j], list.elements[i]
+	})
+
+	return nil
+}
+
+// seed restarts the generator of the interpreter from the given seed
+func seed(intr *Interpreter, n int64) {
+	intr.random.mutex.Lock()
+	defer intr.random.mutex.Unlock()
+
+	intr.random.rng.Seed(n)
+}
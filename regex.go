@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Compiled patterns are shared by every interpreter, the cache is dropped
+// once it grows too large so scripts building patterns dynamically can't
+// make it grow forever
+const REGEX_CACHE_SIZE = 256
+
+// LoxRegex is a compiled pattern, matches are returned as maps with the
+// matched text, its start and end (counted in characters), the captured
+// groups and the named groups
+type LoxRegex struct {
+	Pattern string `lox:"pattern,readonly"`
+
+	re *regexp.Regexp
+}
+
+var regexCache = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"regex": compileRegex,
+	})
+}
+
+func compileRegex(pattern string) (*LoxRegex, error) {
+	regexCache.Lock()
+	defer regexCache.Unlock()
+
+	if re, ok := regexCache.patterns[pattern]; ok {
+		return &LoxRegex{Pattern: pattern, re: re}, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("Invalid regular expression '%v': %v '%v'", pattern, syntaxErr.Code, syntaxErr.Expr)
+		}
+
+		return nil, fmt.Errorf("Invalid regular expression '%v': %v", pattern, err.Error())
+	}
+
+	if len(regexCache.patterns) >= REGEX_CACHE_SIZE {
+		regexCache.patterns = make(map[string]*regexp.Regexp)
+	}
+	regexCache.patterns[pattern] = re
+
+	return &LoxRegex{Pattern: pattern, re: re}, nil
+}
+
+// Match reports whether the pattern matches anywhere in the string
+func (regex *LoxRegex) Match(s string) bool {
+	return regex.re.MatchString(s)
+}
+
+// Find returns the first match or nil
+func (regex *LoxRegex) Find(s string) interface{} {
+	indices := regex.re.FindStringSubmatchIndex(s)
+	if indices == nil {
+		return nil
+	}
+
+	return regex.match(s, indices)
+}
+
+// FindAll returns a list with up to limit matches, all of them by default
+func (regex *LoxRegex) FindAll(s string, limit ...int) (*LoxList, error) {
+	n, err := regexLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []interface{}
+	for _, indices := range regex.re.FindAllStringSubmatchIndex(s, n) {
+		matches = append(matches, regex.match(s, indices))
+	}
+
+	return NewLoxList(matches...), nil
+}
+
+// Replace replaces every match, $1 or ${name} in the replacement refer to
+// captured groups and $$ is a literal dollar sign
+func (regex *LoxRegex) Replace(intr *Interpreter, s string, replacement string) (string, error) {
+	// every match is replaced by the replacement where each $ may expand to
+	// at most the whole match, the unused part is given back afterwards
+	references := strings.Count(replacement, "$")
+	size := len(s)
+	for _, indices := range regex.re.FindAllStringIndex(s, -1) {
+		size += len(replacement) + references*(indices[1]-indices[0])
+	}
+
+	if err := intr.reserve(Token{}, size); err != nil {
+		return "", err
+	}
+
+	result := regex.re.ReplaceAllString(s, replacement)
+	intr.free(size - len(result))
+	return result, nil
+}
+
+// Split returns the substrings between matches, limit works like in FindAll
+func (regex *LoxRegex) Split(s string, limit ...int) ([]string, error) {
+	n, err := regexLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return regex.re.Split(s, n), nil
+}
+
+func (regex *LoxRegex) String() string {
+	return fmt.Sprintf("<regex %v>", regex.Pattern)
+}
+
+func (regex *LoxRegex) match(s string, indices []int) *LoxMap {
+	groups := make([]interface{}, len(indices)/2-1)
+	named := NewLoxMap()
+
+	for i := 1; i < len(indices)/2; i += 1 {
+		var group interface{}
+		if start, end := indices[2*i], indices[2*i+1]; start >= 0 {
+			group = s[start:end]
+		}
+
+		groups[i-1] = group
+		if name := regex.re.SubexpNames()[i]; name != "" {
+			named.put(name, group)
+		}
+	}
+
+	m := NewLoxMap()
+	m.put("match", s[indices[0]:indices[1]])
+	m.put("start", float64(utf8.RuneCountInString(s[:indices[0]])))
+	m.put("end", float64(utf8.RuneCountInString(s[:indices[1]])))
+	m.put("groups", NewLoxList(groups...))
+	m.put("named", named)
+	return m
+}
+
+func regexLimit(limit []int) (int, error) {
+	if len(limit) > 1 {
+		return 0, fmt.Errorf("Expected at most 2 arguments but got %v", len(limit)+1)
+	} else if len(limit) == 1 {
+		if limit[0] < 0 {
+			return 0, errors.New("Limit can't be negative")
+		}
+
+		return limit[0], nil
+	}
+
+	return -1, nil
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var keywords = map[string]TokenType{
@@ -22,15 +25,29 @@ var keywords = map[string]TokenType{
 	"true":   TRUE,
 	"var":    VAR,
 	"while":  WHILE,
+	"yield":  YIELD,
+	"in":     IN,
+	"async":  ASYNC,
+	"await":  AWAIT,
+	"import": IMPORT,
+	"from":   FROM,
+	"as":     AS,
 }
 
 type Scanner struct {
 	content string
 	Tokens  []Token
+	Errors  []SyntaxError
 
-	start   int
-	current int
-	line    int
+	// positions are byte offsets into the content, columns count runes
+	start       int
+	current     int
+	line        int
+	column      int
+	startColumn int
+
+	// brace depth inside every ${...} being scanned, innermost last
+	interpolations []int
 }
 
 func NewScanner(content string) *Scanner {
@@ -39,15 +56,23 @@ func NewScanner(content string) *Scanner {
 		start:   0,
 		current: 0,
 		line:    1,
+		column:  1,
 	}
 }
 
 func (sc *Scanner) ScanTokens() []Token {
 	for !sc.isAtEnd() {
 		sc.start = sc.current
+		sc.startColumn = sc.column
 		sc.scanToken()
 	}
 
+	sc.startColumn = sc.column
+
+	if len(sc.interpolations) > 0 {
+		sc.error("Unterminated string interpolation")
+	}
+
 	sc.addToken(EOF)
 	return sc.Tokens
 }
@@ -64,8 +89,21 @@ func (sc *Scanner) scanToken() {
 	case ')':
 		sc.addToken(RIGHT_PAREN)
 	case '{':
+		if len(sc.interpolations) > 0 {
+			sc.interpolations[len(sc.interpolations)-1] += 1
+		}
 		sc.addToken(LEFT_BRACE)
 	case '}':
+		if depth := len(sc.interpolations); depth > 0 && sc.interpolations[depth-1] == 0 {
+			// end of an interpolated expression, the string continues after it
+			sc.interpolations = sc.interpolations[:depth-1]
+			sc.string(sc.current)
+			return
+		}
+
+		if len(sc.interpolations) > 0 {
+			sc.interpolations[len(sc.interpolations)-1] -= 1
+		}
 		sc.addToken(RIGHT_BRACE)
 	case ',':
 		sc.addToken(COMMA)
@@ -95,7 +133,7 @@ func (sc *Scanner) scanToken() {
 		if sc.match('=') {
 			sc.addToken(BANG_EQUAL)
 		} else {
-			sc.addToken(EQUAL)
+			sc.addToken(BANG)
 		}
 	case '=':
 		if sc.match('=') {
@@ -116,39 +154,64 @@ func (sc *Scanner) scanToken() {
 			sc.addToken(LESS)
 		}
 
-	// Ignore whitespace
-	case ' ', '\r', '\t':
-		return
-
-	// New lines
-	case '\n':
-		sc.line += 1
+	// Ignore whitespace (new lines are counted by advance)
+	case ' ', '\r', '\t', '\n':
 		return
 
 	// Literals
 	case '"':
-		sc.string()
+		if sc.peek() == '"' && sc.peekNext() == '"' {
+			sc.advance()
+			sc.advance()
+			sc.multilineString()
+		} else {
+			sc.string(sc.current)
+		}
+	case '`':
+		sc.rawString()
 
 	default:
 		// Numbers
-		if unicode.IsDigit(rune(c)) {
+		if isDigit(c) {
 			sc.number()
 			return
 		}
 
 		// Identifiers
-		if unicode.IsLetter(rune(c)) || c == '_' {
+		if unicode.IsLetter(c) || c == '_' {
 			sc.identifier()
 			return
 		}
 
-		LoxError(sc.line, "Unexpected character")
+		// invalid encodings are reported by advance
+		if c != utf8.RuneError {
+			sc.error(fmt.Sprintf("Unexpected character '%c'", c))
+		}
 	}
 }
 
-func (sc *Scanner) advance() byte {
-	c := sc.content[sc.current]
-	sc.current += 1
+func (sc *Scanner) error(message string) {
+	sc.Errors = append(sc.Errors, SyntaxError{
+		Line:    sc.line,
+		Message: fmt.Sprintf("%v at column %v", message, sc.column-1),
+	})
+}
+
+func (sc *Scanner) advance() rune {
+	c, size := utf8.DecodeRuneInString(sc.content[sc.current:])
+	sc.current += size
+
+	if c == '\n' {
+		sc.line += 1
+		sc.column = 1
+	} else {
+		sc.column += 1
+	}
+
+	if c == utf8.RuneError && size == 1 {
+		sc.error("Invalid UTF-8 encoding")
+	}
+
 	return c
 }
 
@@ -162,10 +225,12 @@ func (sc *Scanner) addTokenWithLiteral(tokenType TokenType, literal interface{})
 		lexeme = sc.content[sc.start:sc.current]
 	}
 
-	sc.Tokens = append(sc.Tokens, NewToken(tokenType, lexeme, literal, sc.line))
+	token := NewToken(tokenType, lexeme, literal, sc.line)
+	token.Column = sc.startColumn
+	sc.Tokens = append(sc.Tokens, token)
 }
 
-func (sc *Scanner) match(c byte) bool {
+func (sc *Scanner) match(c rune) bool {
 	if sc.peek() != c {
 		return false
 	}
@@ -174,31 +239,51 @@ func (sc *Scanner) match(c byte) bool {
 	return true
 }
 
-func (sc *Scanner) peek() byte {
+func (sc *Scanner) peek() rune {
 	if sc.isAtEnd() {
 		return 0
 	} else {
-		return sc.content[sc.current]
+		c, _ := utf8.DecodeRuneInString(sc.content[sc.current:])
+		return c
 	}
 }
 
-func (sc *Scanner) peekNext() byte {
-	if sc.current+1 >= len(sc.content) {
+func (sc *Scanner) peekNext() rune {
+	return sc.peekAt(1)
+}
+
+// peekAt looks at the rune the given number of runes after the current one
+func (sc *Scanner) peekAt(distance int) rune {
+	position := sc.current
+	for i := 0; i < distance && position < len(sc.content); i += 1 {
+		_, size := utf8.DecodeRuneInString(sc.content[position:])
+		position += size
+	}
+
+	if position >= len(sc.content) {
 		return 0
-	} else {
-		return sc.content[sc.current+1]
 	}
+
+	c, _ := utf8.DecodeRuneInString(sc.content[position:])
+	return c
 }
 
-func (sc *Scanner) string() {
+// string scans the rest of a string starting at the given offset, when it
+// finds a ${ it emits the text so far as an INTERPOLATION token and returns so
+// the expression is scanned as regular tokens
+func (sc *Scanner) string(segmentStart int) {
 	for sc.peek() != '"' && !sc.isAtEnd() {
-		if sc.peek() == '\n' {
-			sc.line += 1
-		}
-
 		// we consume the character after the \ so we support things like \"
 		if sc.peek() == '\\' {
 			sc.advance()
+		} else if sc.peek() == '$' && sc.peekNext() == '{' {
+			raw := sc.content[segmentStart:sc.current]
+			sc.advance()
+			sc.advance()
+
+			sc.interpolations = append(sc.interpolations, 0)
+			sc.addTokenWithLiteral(INTERPOLATION, sc.unescape(raw))
+			return
 		}
 
 		// as we can advance above we want to check we can still consume characters
@@ -208,39 +293,170 @@ func (sc *Scanner) string() {
 	}
 
 	if sc.isAtEnd() {
-		LoxError(sc.line, "Unterminated string")
+		sc.error("Unterminated string")
+		return
 	}
 
 	sc.advance() // closing quote (")
 
-	value := sc.content[sc.start+1 : sc.current-1] // trim surrounding quotes
-	sc.addTokenWithLiteral(STRING, value)
+	raw := sc.content[segmentStart : sc.current-1] // trim closing quote
+	sc.addTokenWithLiteral(STRING, sc.unescape(raw))
+}
+
+// multilineString scans a """triple-quoted""" string, the indentation shared
+// by all its lines is removed so it can be indented along with the code
+func (sc *Scanner) multilineString() {
+	for !sc.isAtEnd() && !(sc.peek() == '"' && sc.peekNext() == '"' && sc.peekAt(2) == '"') {
+		if sc.advance() == '\\' && !sc.isAtEnd() {
+			sc.advance()
+		}
+	}
+
+	if sc.isAtEnd() {
+		sc.error("Unterminated string")
+		return
+	}
+
+	sc.advance()
+	sc.advance()
+	sc.advance()
+
+	raw := sc.content[sc.start+3 : sc.current-3] // trim surrounding quotes
+	sc.addTokenWithLiteral(STRING, sc.unescape(dedent(raw)))
+}
+
+// rawString scans a `backtick` string where backslashes have no special meaning
+func (sc *Scanner) rawString() {
+	for sc.peek() != '`' && !sc.isAtEnd() {
+		sc.advance()
+	}
+
+	if sc.isAtEnd() {
+		sc.error("Unterminated string")
+		return
+	}
+
+	sc.advance() // closing backtick
+
+	sc.addTokenWithLiteral(STRING, sc.content[sc.start+1:sc.current-1])
+}
+
+func (sc *Scanner) unescape(raw string) string {
+	if !strings.ContainsRune(raw, '\\') {
+		return raw
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(raw); i += 1 {
+		if raw[i] != '\\' || i+1 >= len(raw) {
+			sb.WriteByte(raw[i])
+			continue
+		}
+
+		i += 1
+		switch raw[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '0':
+			sb.WriteByte(0)
+		case '\\', '"', '\'', '`', '$':
+			sb.WriteByte(raw[i])
+		case 'x':
+			// \x41 (exactly two hex digits)
+			if i+2 < len(raw) {
+				if code, err := strconv.ParseUint(raw[i+1:i+3], 16, 8); err == nil {
+					sb.WriteRune(rune(code))
+					i += 2
+					continue
+				}
+			}
+
+			sc.error("Invalid escape sequence '\\x', expected two hex digits")
+		case 'u':
+			// \u{1F600} (one to six hex digits)
+			end := strings.IndexByte(raw[i:], '}')
+			if i+1 < len(raw) && raw[i+1] == '{' && end > 2 && end <= 8 {
+				code, err := strconv.ParseUint(raw[i+2:i+end], 16, 32)
+				if err == nil && utf8.ValidRune(rune(code)) {
+					sb.WriteRune(rune(code))
+					i += end
+					continue
+				}
+			}
+
+			sc.error("Invalid escape sequence '\\u', expected a code point like \\u{1F600}")
+		default:
+			c, _ := utf8.DecodeRuneInString(raw[i:])
+			sc.error(fmt.Sprintf("Unknown escape sequence '\\%c'", c))
+		}
+	}
+
+	return sb.String()
+}
+
+// dedent drops the line breaks right after the opening and before the closing
+// quotes and removes the whitespace prefix common to every non-blank line
+func dedent(raw string) string {
+	lines := strings.Split(raw, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		} else if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		}
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func (sc *Scanner) number() {
-	for unicode.IsDigit(rune(sc.peek())) {
+	for isDigit(sc.peek()) {
 		sc.advance()
 	}
 
 	// fractional part (optional)
-	if sc.peek() == '.' && unicode.IsDigit(rune(sc.peekNext())) {
+	if sc.peek() == '.' && isDigit(sc.peekNext()) {
 		sc.advance() // consume the '.'
 
-		for unicode.IsDigit(rune(sc.peek())) {
+		for isDigit(sc.peek()) {
 			sc.advance()
 		}
 	}
 
 	value, err := strconv.ParseFloat(sc.content[sc.start:sc.current], 64)
 	if err != nil {
-		LoxError(sc.line, err.Error())
+		sc.error(err.Error())
 	}
 
 	sc.addTokenWithLiteral(NUMBER, value)
 }
 
 func (sc *Scanner) identifier() {
-	for unicode.IsLetter(rune(sc.peek())) || unicode.IsDigit(rune(sc.peek())) || sc.peek() == '_' {
+	for unicode.IsLetter(sc.peek()) || unicode.IsDigit(sc.peek()) || sc.peek() == '_' {
 		sc.advance()
 	}
 
@@ -261,8 +477,6 @@ func (sc *Scanner) multilineComment() {
 		} else if sc.peek() == '/' && sc.peekNext() == '*' {
 			depth += 1
 			sc.advance()
-		} else if sc.peek() == '\n' {
-			sc.line += 1
 		}
 
 		// as we can advance above we want to prevent overflow
@@ -272,6 +486,11 @@ func (sc *Scanner) multilineComment() {
 	}
 
 	if depth > 0 {
-		LoxError(sc.line, "Multiline comment was not closed")
+		sc.error("Multiline comment was not closed")
 	}
 }
+
+// only ASCII digits are valid in number literals
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// runScript runs the source on a new interpreter and returns what it printed
+// to stdout and stderr
+func runScript(t *testing.T, source string, options ...InterpreterOption) (*Interpreter, string, string) {
+	t.Helper()
+
+	program, err := Compile(source)
+	if err != nil {
+		t.Fatalf("compiling %q failed: %v", source, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	options = append(options, WithStdout(&stdout), WithStderr(&stderr))
+	intr := NewInterpreter(options...)
+	defer intr.Close()
+
+	intr.Run(program)
+	intr.RunEventLoop()
+
+	return intr, stdout.String(), stderr.String()
+}
@@ -5,9 +5,11 @@ type Stmt interface {
 }
 
 type StmtFunction struct {
-	Name       Token
-	Parameters []Token
-	Body       []Stmt
+	Name        Token
+	Parameters  []Token
+	Body        []Stmt
+	IsGenerator bool
+	IsAsync     bool
 }
 
 type StmtWhile struct {
@@ -15,6 +17,12 @@ type StmtWhile struct {
 	Body      Stmt
 }
 
+type StmtForIn struct {
+	Name     Token
+	Iterable Expr
+	Body     Stmt
+}
+
 type StmtIf struct {
 	Condition  Expr
 	ThenBranch Stmt
@@ -22,6 +30,7 @@ type StmtIf struct {
 }
 
 type StmtBlock struct {
+	Brace      Token
 	Statements []Stmt
 }
 
@@ -34,8 +43,17 @@ type StmtExpression struct {
 	Expression Expr
 }
 
+// StmtPrint prints its expressions separated by spaces
 type StmtPrint struct {
-	Expression Expr
+	Expressions []Expr
+}
+
+// StmtImport is either `import "path" as alias;` or `from "path" import a, b;`
+type StmtImport struct {
+	Keyword Token
+	Path    Token
+	Alias   *Token
+	Names   []Token
 }
 
 type StmtReturn struct {
@@ -43,6 +61,11 @@ type StmtReturn struct {
 	Expression Expr
 }
 
+type StmtYield struct {
+	Keyword    Token
+	Expression Expr
+}
+
 func (stmt StmtFunction) accept(visitor StmtVisitor) error {
 	return visitor.VisitStmtFunction(stmt)
 }
@@ -51,6 +74,10 @@ func (stmt StmtWhile) accept(visitor StmtVisitor) error {
 	return visitor.VisitStmtWhile(stmt)
 }
 
+func (stmt StmtForIn) accept(visitor StmtVisitor) error {
+	return visitor.VisitStmtForIn(stmt)
+}
+
 func (stmt StmtIf) accept(visitor StmtVisitor) error {
 	return visitor.VisitStmtIf(stmt)
 }
@@ -74,3 +101,11 @@ func (stmt StmtPrint) accept(visitor StmtVisitor) error {
 func (stmt StmtReturn) accept(visitor StmtVisitor) error {
 	return visitor.VisitStmtReturn(stmt)
 }
+
+func (stmt StmtYield) accept(visitor StmtVisitor) error {
+	return visitor.VisitStmtYield(stmt)
+}
+
+func (stmt StmtImport) accept(visitor StmtVisitor) error {
+	return visitor.VisitStmtImport(stmt)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// syncWriter serializes writes so scripts spawning goroutines, or a pool of
+// interpreters sharing the same writer, don't interleave partial output
+type syncWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// lineReader is shared by every interpreter reading from the same source so
+// buffered input is not lost between them
+type lineReader struct {
+	mutex  sync.Mutex
+	reader *bufio.Reader
+}
+
+var stdinReader = newLineReader(os.Stdin)
+
+func init() {
+	registerNatives(CAPABILITY_IO, map[string]interface{}{
+		"input":    input,
+		"readLine": readLine,
+	})
+}
+
+func newSyncWriter(writer io.Writer) *syncWriter {
+	return &syncWriter{writer: writer}
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	return sw.writer.Write(p)
+}
+
+func newLineReader(reader io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReader(reader)}
+}
+
+// readLine returns the next line without its line terminator, ok is false
+// once the input is exhausted
+func (lr *lineReader) readLine() (line string, ok bool, err error) {
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+
+	line, err = lr.reader.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+
+		err = nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, true, nil
+}
+
+// input writes the optional prompt and reads a line, it returns nil at the end
+// of the input
+func input(intr *Interpreter, prompt ...interface{}) (interface{}, error) {
+	if len(prompt) > 1 {
+		return nil, fmt.Errorf("Expected at most 1 argument but got %v", len(prompt))
+	}
+
+	if len(prompt) == 1 {
+		str, err := intr.stringify(prompt[0])
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprint(intr.stdout, str)
+	}
+
+	return readLine(intr)
+}
+
+func readLine(intr *Interpreter) (interface{}, error) {
+	line, ok, err := intr.stdin.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("Can't read input: %v", err.Error())
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	if err := intr.allocate(Token{}, len(line)); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Strings are indexed by code points rather than bytes
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"len":        length,
+		"substr":     substr,
+		"indexOf":    indexOf,
+		"contains":   strings.Contains,
+		"startsWith": strings.HasPrefix,
+		"endsWith":   strings.HasSuffix,
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"split":      strings.Split,
+		"join":       join,
+		"replace":    replace,
+		"repeat":     repeat,
+		"charAt":     charAt,
+	})
+}
+
+func length(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case string:
+		return utf8.RuneCountInString(v), nil
+	case *LoxList:
+		return v.Len(), nil
+	case *LoxMap:
+		return v.Len(), nil
+	default:
+		return 0, fmt.Errorf("Can't get the length of %v", typeName(value))
+	}
+}
+
+// substr returns the characters between start and end (exclusive, defaults
+// to the end of the string), negative positions count from the end
+func substr(s string, start int, end ...int) (string, error) {
+	runes := []rune(s)
+
+	stop := len(runes)
+	if len(end) > 1 {
+		return "", errors.New("Expected at most 3 arguments")
+	} else if len(end) == 1 {
+		stop = end[0]
+	}
+
+	start, stop = clampIndex(start, len(runes)), clampIndex(stop, len(runes))
+	if start >= stop {
+		return "", nil
+	}
+
+	return string(runes[start:stop]), nil
+}
+
+func clampIndex(index int, length int) int {
+	if index < 0 {
+		index += length
+	}
+
+	if index < 0 {
+		return 0
+	} else if index > length {
+		return length
+	}
+
+	return index
+}
+
+func indexOf(s string, substring string) int {
+	index := strings.Index(s, substring)
+	if index < 0 {
+		return -1
+	}
+
+	return utf8.RuneCountInString(s[:index])
+}
+
+func join(intr *Interpreter, list *LoxList, separator string) (string, error) {
+	var parts []string
+	for _, element := range list.Elements() {
+		str, err := intr.stringify(element)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, str)
+	}
+
+	size := 0
+	for _, part := range parts {
+		size += len(part) + len(separator)
+	}
+
+	if err := intr.reserve(Token{}, size); err != nil {
+		return "", err
+	}
+
+	return strings.Join(parts, separator), nil
+}
+
+func replace(intr *Interpreter, s string, old string, new string) (string, error) {
+	// an empty old string matches around every character
+	count := utf8.RuneCountInString(s) + 1
+	if old != "" {
+		count = strings.Count(s, old)
+	}
+
+	if err := intr.reserve(Token{}, len(s)+count*(len(new)-len(old))); err != nil {
+		return "", err
+	}
+
+	return strings.ReplaceAll(s, old, new), nil
+}
+
+func repeat(intr *Interpreter, s string, count int) (string, error) {
+	if count < 0 {
+		return "", errors.New("Repeat count can't be negative")
+	}
+
+	// checked before multiplying since the size could overflow
+	if len(s) > 0 && count > MAX_ALLOCATION/len(s) {
+		return "", errTooLarge(Token{})
+	}
+
+	if err := intr.reserve(Token{}, len(s)*count); err != nil {
+		return "", err
+	}
+
+	return strings.Repeat(s, count), nil
+}
+
+func charAt(s string, index int) (string, error) {
+	runes := []rune(s)
+	if index < 0 || index >= len(runes) {
+		return "", fmt.Errorf("Index %v out of range for string of length %v", index, len(runes))
+	}
+
+	return string(runes[index]), nil
+}
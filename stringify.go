@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerNatives(CAPABILITY_CORE, map[string]interface{}{
+		"toString": (*Interpreter).stringify,
+	})
+}
+
+// stringify is the canonical string representation of a Lox value, it is
+// used by print, concatenation, interpolation and natives alike. Objects can
+// customize it with a toString() method
+func (intr *Interpreter) stringify(value interface{}) (string, error) {
+	return intr.stringifyValue(value, make(map[interface{}]bool))
+}
+
+// seen holds the collections being rendered so cycles are not followed
+func (intr *Interpreter) stringifyValue(value interface{}, seen map[interface{}]bool) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "nil", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return formatNumber(v), nil
+	case string:
+		return v, nil
+	case FunctionLoxCallable:
+		return fmt.Sprintf("<fn %v>", v.declaration.Name.Lexeme), nil
+	case NativeLoxCallable:
+		return fmt.Sprintf("<native fn %v>", v.name), nil
+	case DeniedLoxCallable:
+		return fmt.Sprintf("<native fn %v>", v.name), nil
+	case *LoxGenerator:
+		return fmt.Sprintf("<generator %v>", v.name), nil
+	case *LoxPromise:
+		return "<promise>", nil
+	case *LoxModule:
+		return fmt.Sprintf("<module %v>", v.path), nil
+	case *LoxList:
+		if seen[v] {
+			return "[...]", nil
+		}
+		seen[v] = true
+		defer delete(seen, v)
+
+		var parts []string
+		for _, element := range v.Elements() {
+			str, err := intr.stringifyElement(element, seen)
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, str)
+		}
+
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case *LoxMap:
+		if seen[v] {
+			return "{...}", nil
+		}
+		seen[v] = true
+		defer delete(seen, v)
+
+		var parts []string
+		for _, key := range v.Keys() {
+			keyStr, err := intr.stringifyElement(key, seen)
+			if err != nil {
+				return "", err
+			}
+
+			value, _ := v.Lookup(key)
+			valueStr, err := intr.stringifyElement(value, seen)
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, keyStr+": "+valueStr)
+		}
+
+		return "{" + strings.Join(parts, ", ") + "}", nil
+	case LoxObject:
+		return intr.stringifyObject(v)
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+// stringifyValues renders the values separated by spaces like print does
+func (intr *Interpreter) stringifyValues(values []interface{}) (string, error) {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		str, err := intr.stringify(value)
+		if err != nil {
+			return "", err
+		}
+
+		parts[i] = str
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// stringifyElement renders values inside collections, strings are quoted so
+// ["a, b"] and ["a", "b"] can be told apart
+func (intr *Interpreter) stringifyElement(value interface{}, seen map[interface{}]bool) (string, error) {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s), nil
+	}
+
+	return intr.stringifyValue(value, seen)
+}
+
+func (intr *Interpreter) stringifyObject(obj LoxObject) (string, error) {
+	token := NewToken(IDENTIFIER, "toString", nil, 0)
+
+	if method, err := obj.Get(token); err == nil {
+		if _, ok := method.(LoxCallable); ok {
+			value, err := intr.call(method, nil, token)
+			if err != nil {
+				return "", err
+			}
+
+			if str, ok := value.(string); ok {
+				return str, nil
+			}
+
+			return "", RuntimeError{
+				Token:   token,
+				Message: fmt.Sprintf("toString() must return a string but returned %v", typeName(value)),
+			}
+		}
+	}
+
+	if goObj, ok := obj.(*GoObject); ok {
+		if stringer, ok := goObj.value.Interface().(fmt.Stringer); ok {
+			return stringer.String(), nil
+		}
+
+		return fmt.Sprintf("<%v instance>", goObj.structValue().Type().Name()), nil
+	}
+
+	return fmt.Sprintf("<%T>", obj), nil
+}
+
+// formatNumber renders numbers without an exponent unless they are very
+// large or very small, integral numbers have no decimals
+func formatNumber(f float64) string {
+	if abs := math.Abs(f); f == math.Trunc(f) && abs < 1e21 || abs >= 1e-7 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
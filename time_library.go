@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Durations are numbers of milliseconds, like the arguments of sleep and timer
+
+// LoxDate is an instant in a time zone, dates are immutable so their fields
+// are computed once
+type LoxDate struct {
+	Year        int     `lox:"year,readonly"`
+	Month       int     `lox:"month,readonly"`
+	Day         int     `lox:"day,readonly"`
+	Hour        int     `lox:"hour,readonly"`
+	Minute      int     `lox:"minute,readonly"`
+	Second      int     `lox:"second,readonly"`
+	Millisecond int     `lox:"millisecond,readonly"`
+	Weekday     int     `lox:"weekday,readonly"` // 0 is Sunday
+	Zone        string  `lox:"zone,readonly"`
+	Timestamp   float64 `lox:"timestamp,readonly"` // seconds since the Unix epoch
+
+	time time.Time
+}
+
+// Layouts that can be given by name to formatTime and parseTime, any other
+// layout uses Go's reference time (Mon Jan 2 15:04:05 MST 2006)
+var timeLayouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"RFC1123":  time.RFC1123,
+	"DateTime": time.DateTime,
+	"DateOnly": time.DateOnly,
+	"TimeOnly": time.TimeOnly,
+	"Kitchen":  time.Kitchen,
+}
+
+func init() {
+	registerNatives(CAPABILITY_TIME, map[string]interface{}{
+		"clock":          clock,
+		"now":            now,
+		"ticks":          ticks,
+		"date":           newDate,
+		"fromTimestamp":  fromTimestamp,
+		"formatTime":     formatTime,
+		"parseTime":      parseTime,
+		"parseDuration":  parseDuration,
+		"formatDuration": formatDuration,
+	})
+}
+
+func newLoxDate(t time.Time) *LoxDate {
+	return &LoxDate{
+		Year:        t.Year(),
+		Month:       int(t.Month()),
+		Day:         t.Day(),
+		Hour:        t.Hour(),
+		Minute:      t.Minute(),
+		Second:      t.Second(),
+		Millisecond: t.Nanosecond() / int(time.Millisecond),
+		Weekday:     int(t.Weekday()),
+		Zone:        t.Location().String(),
+		Timestamp:   float64(t.UnixNano()) / float64(time.Second),
+		time:        t,
+	}
+}
+
+// clock returns the seconds since the Unix epoch with sub-second precision
+func clock() float64 {
+	return float64(time.Now().UnixNano()) / float64(time.Second)
+}
+
+func now() *LoxDate {
+	return newLoxDate(time.Now())
+}
+
+// ticks returns the milliseconds since the interpreter started, unlike clock
+// it never goes backwards when the system time changes
+func ticks(intr *Interpreter) float64 {
+	return float64(time.Since(intr.start)) / float64(time.Millisecond)
+}
+
+// newDate builds a local date from its year, month and day, optionally
+// followed by the hour, minute and (possibly fractional) second
+func newDate(year int, month int, day int, rest ...float64) (*LoxDate, error) {
+	if len(rest) > 3 {
+		return nil, fmt.Errorf("Expected at most 6 arguments but got %v", len(rest)+3)
+	}
+
+	var hour, minute int
+	var seconds float64
+	for i, value := range rest {
+		if i < 2 && value != math.Trunc(value) {
+			return nil, fmt.Errorf("Argument %v of 'date' must be an integer but got %v", i+4, formatNumber(value))
+		}
+
+		switch i {
+		case 0:
+			hour = int(value)
+		case 1:
+			minute = int(value)
+		case 2:
+			seconds = value
+		}
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, minute, 0, 0, time.Local)
+	return newLoxDate(t.Add(durationOf(seconds * 1000))), nil
+}
+
+// fromTimestamp returns the local date of the seconds since the Unix epoch
+func fromTimestamp(seconds float64) *LoxDate {
+	whole, fraction := math.Modf(seconds)
+	return newLoxDate(time.Unix(int64(whole), int64(fraction*float64(time.Second))))
+}
+
+func formatTime(date *LoxDate, layout ...string) (string, error) {
+	goLayout, err := timeLayout(layout)
+	if err != nil {
+		return "", err
+	}
+
+	return date.time.Format(goLayout), nil
+}
+
+// parseTime reads a date with the layout (RFC3339 by default), times without
+// a zone are taken to be in the given one or the local time zone
+func parseTime(text string, options ...string) (*LoxDate, error) {
+	if len(options) > 2 {
+		return nil, fmt.Errorf("Expected at most 3 arguments but got %v", len(options)+1)
+	}
+
+	var layout []string
+	if len(options) > 0 {
+		layout = options[:1]
+	}
+
+	goLayout, err := timeLayout(layout)
+	if err != nil {
+		return nil, err
+	}
+
+	location := time.Local
+	if len(options) == 2 {
+		if location, err = loadZone(options[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := time.ParseInLocation(goLayout, text, location)
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse '%v' as a time with layout '%v'", text, goLayout)
+	}
+
+	return newLoxDate(t), nil
+}
+
+// parseDuration accepts Go durations such as "1h30m" or "250ms"
+func parseDuration(text string) (float64, error) {
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid duration '%v'", text)
+	}
+
+	return float64(duration) / float64(time.Millisecond), nil
+}
+
+func formatDuration(milliseconds float64) string {
+	return durationOf(milliseconds).String()
+}
+
+// Add returns the date moved by the given milliseconds
+func (date *LoxDate) Add(milliseconds float64) *LoxDate {
+	return newLoxDate(date.time.Add(durationOf(milliseconds)))
+}
+
+// Since returns the milliseconds elapsed from the other date to this one
+func (date *LoxDate) Since(other *LoxDate) float64 {
+	return float64(date.time.Sub(other.time)) / float64(time.Millisecond)
+}
+
+// InZone returns the same instant in another time zone such as "UTC",
+// "Local" or "Europe/Paris"
+func (date *LoxDate) InZone(zone string) (*LoxDate, error) {
+	location, err := loadZone(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLoxDate(date.time.In(location)), nil
+}
+
+func (date *LoxDate) String() string {
+	return date.time.Format(time.RFC3339Nano)
+}
+
+func timeLayout(layout []string) (string, error) {
+	if len(layout) > 1 {
+		return "", fmt.Errorf("Expected at most 2 arguments but got %v", len(layout)+1)
+	} else if len(layout) == 0 {
+		return time.RFC3339, nil
+	}
+
+	if named, ok := timeLayouts[layout[0]]; ok {
+		return named, nil
+	}
+
+	return layout[0], nil
+}
+
+func loadZone(zone string) (*time.Location, error) {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown time zone '%v'", zone)
+	}
+
+	return location, nil
+}
+
+func durationOf(milliseconds float64) time.Duration {
+	return time.Duration(milliseconds * float64(time.Millisecond))
+}
@@ -5,6 +5,7 @@ type Token struct {
 	Lexeme    string
 	Literal   interface{}
 	Line      int
+	Column    int
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int) Token {
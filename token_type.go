@@ -27,9 +27,10 @@ const (
 	LESS          = "LESS"
 
 	// Literals
-	STRING     = "STRING"
-	NUMBER     = "NUMBER"
-	IDENTIFIER = "IDENTIFIER"
+	STRING        = "STRING"
+	INTERPOLATION = "INTERPOLATION"
+	NUMBER        = "NUMBER"
+	IDENTIFIER    = "IDENTIFIER"
 
 	// Keywords
 	AND    = "AND"
@@ -48,6 +49,13 @@ const (
 	TRUE   = "TRUE"
 	VAR    = "VAR"
 	WHILE  = "WHILE"
+	YIELD  = "YIELD"
+	IN     = "IN"
+	ASYNC  = "ASYNC"
+	AWAIT  = "AWAIT"
+	IMPORT = "IMPORT"
+	FROM   = "FROM"
+	AS     = "AS"
 
 	EOF = "EOF"
 )
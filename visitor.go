@@ -9,6 +9,10 @@ type ExprVisitor interface {
 	VisitExprAssign(expr ExprAssign) (interface{}, error)
 	VisitExprLogical(expr ExprLogical) (interface{}, error)
 	VisitExprCall(expr ExprCall) (interface{}, error)
+	VisitExprGet(expr ExprGet) (interface{}, error)
+	VisitExprSet(expr ExprSet) (interface{}, error)
+	VisitExprAwait(expr ExprAwait) (interface{}, error)
+	VisitExprInterpolation(expr ExprInterpolation) (interface{}, error)
 }
 
 type StmtVisitor interface {
@@ -19,5 +23,8 @@ type StmtVisitor interface {
 	VisitStmtFunction(stmt StmtFunction) error
 	VisitStmtReturn(stmt StmtReturn) error
 	VisitStmtWhile(stmt StmtWhile) error
+	VisitStmtForIn(stmt StmtForIn) error
+	VisitStmtYield(stmt StmtYield) error
+	VisitStmtImport(stmt StmtImport) error
 	VisitStmtIf(stmt StmtIf) error
 }